@@ -0,0 +1,190 @@
+package bot
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+// ValidatorShare describes a validator the delegator claims rewards from
+type ValidatorShare struct {
+	ValidatorAddress string
+	// Rewards and Delegated are amounts of the native denom
+	Rewards   int64
+	Delegated int64
+}
+
+// Allocation is the amount delegated to a validator by a restake
+type Allocation struct {
+	ValidatorAddress string
+	Amount           int64
+}
+
+// Allocator splits the stakable balance of a delegator between validators. Amounts are
+// rounded down, leaving the remainder undelegated.
+type Allocator interface {
+	Allocate(amount int64, validators []ValidatorShare) ([]Allocation, error)
+}
+
+// NewAllocator returns the allocator selected by the record, checking that its target
+// validators belong to the chain
+func NewAllocator(chain types.Chain, record *types.Record) (Allocator, error) {
+	switch record.Allocation {
+	case types.AllocationStrategy_PROPORTIONAL_TO_REWARDS:
+		return RewardsAllocator{}, nil
+	case types.AllocationStrategy_PROPORTIONAL_TO_DELEGATION:
+		return DelegationAllocator{}, nil
+	case types.AllocationStrategy_EQUAL_SPLIT:
+		return EqualAllocator{}, nil
+	case types.AllocationStrategy_SINGLE_VALIDATOR:
+		if err := validateValidator(chain, record.AllocationValidator); err != nil {
+			return nil, err
+		}
+		return SingleValidatorAllocator{Validator: record.AllocationValidator}, nil
+	case types.AllocationStrategy_TARGET_WEIGHTS:
+		if len(record.AllocationWeights) == 0 {
+			return nil, fmt.Errorf("no target weights")
+		}
+		seen := make(map[string]bool, len(record.AllocationWeights))
+		for _, weight := range record.AllocationWeights {
+			if err := validateValidator(chain, weight.ValidatorAddress); err != nil {
+				return nil, err
+			}
+			if seen[weight.ValidatorAddress] {
+				return nil, fmt.Errorf("duplicate weight for validator %s", weight.ValidatorAddress)
+			}
+			seen[weight.ValidatorAddress] = true
+			if !(weight.Weight > 0) || math.IsInf(weight.Weight, 0) {
+				return nil, fmt.Errorf("weight of validator %s must be positive, got %v", weight.ValidatorAddress, weight.Weight)
+			}
+		}
+		return WeightsAllocator{Weights: record.AllocationWeights}, nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %v", record.Allocation)
+	}
+}
+
+func validateValidator(chain types.Chain, address string) error {
+	if address == "" {
+		return fmt.Errorf("no validator specified")
+	}
+	hrp, _, err := bech32.DecodeAndConvert(address)
+	if err != nil {
+		return fmt.Errorf("invalid validator address %s: %w", address, err)
+	}
+	if prefix := chain.Prefix + "valoper"; hrp != prefix {
+		return fmt.Errorf("validator %s does not belong to chain %s (prefix %s)", address, chain.Id, prefix)
+	}
+	return nil
+}
+
+// RewardsAllocator splits the amount in proportion to the rewards claimed from each
+// validator
+type RewardsAllocator struct{}
+
+func (RewardsAllocator) Allocate(amount int64, validators []ValidatorShare) ([]Allocation, error) {
+	weights := make([]*big.Rat, len(validators))
+	for idx, validator := range validators {
+		weights[idx] = new(big.Rat).SetInt64(validator.Rewards)
+	}
+	return split(amount, validators, weights)
+}
+
+// DelegationAllocator splits the amount in proportion to the amount delegated to each
+// validator, keeping the delegations balanced as they grow. Once nothing is delegated
+// anymore, e.g. after fully unbonding while rewards are still claimable, the amount is
+// split in proportion to the rewards instead.
+type DelegationAllocator struct{}
+
+func (DelegationAllocator) Allocate(amount int64, validators []ValidatorShare) ([]Allocation, error) {
+	weights := make([]*big.Rat, len(validators))
+	delegated := false
+	for idx, validator := range validators {
+		weights[idx] = new(big.Rat).SetInt64(validator.Delegated)
+		delegated = delegated || validator.Delegated != 0
+	}
+	if !delegated {
+		return RewardsAllocator{}.Allocate(amount, validators)
+	}
+	return split(amount, validators, weights)
+}
+
+// EqualAllocator splits the amount equally between the validators
+type EqualAllocator struct{}
+
+func (EqualAllocator) Allocate(amount int64, validators []ValidatorShare) ([]Allocation, error) {
+	weights := make([]*big.Rat, len(validators))
+	for idx := range validators {
+		weights[idx] = big.NewRat(1, 1)
+	}
+	return split(amount, validators, weights)
+}
+
+// SingleValidatorAllocator delegates the whole amount to one validator, which need not
+// be one the rewards were claimed from
+type SingleValidatorAllocator struct {
+	Validator string
+}
+
+func (a SingleValidatorAllocator) Allocate(amount int64, _ []ValidatorShare) ([]Allocation, error) {
+	return []Allocation{{ValidatorAddress: a.Validator, Amount: nonNegative(amount)}}, nil
+}
+
+// WeightsAllocator splits the amount in proportion to weights chosen by the delegator,
+// regardless of the validators the rewards were claimed from
+type WeightsAllocator struct {
+	Weights []*types.ValidatorWeight
+}
+
+func (a WeightsAllocator) Allocate(amount int64, _ []ValidatorShare) ([]Allocation, error) {
+	validators := make([]ValidatorShare, len(a.Weights))
+	weights := make([]*big.Rat, len(a.Weights))
+	for idx, weight := range a.Weights {
+		validators[idx] = ValidatorShare{ValidatorAddress: weight.ValidatorAddress}
+		// parse the shortest decimal representation so that a weight of 0.7 is exactly
+		// seven tenths rather than the float closest to it
+		var ok bool
+		weights[idx], ok = new(big.Rat).SetString(strconv.FormatFloat(weight.Weight, 'g', -1, 64))
+		if !ok {
+			return nil, fmt.Errorf("invalid weight %v for validator %s", weight.Weight, weight.ValidatorAddress)
+		}
+	}
+	return split(amount, validators, weights)
+}
+
+// split allocates amount in proportion to the weights of the validators, rounding down
+func split(amount int64, validators []ValidatorShare, weights []*big.Rat) ([]Allocation, error) {
+	total := new(big.Rat)
+	for _, weight := range weights {
+		if weight.Sign() < 0 {
+			return nil, fmt.Errorf("negative weight %s", weight.FloatString(6))
+		}
+		total.Add(total, weight)
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("nothing to allocate by, all %d weights are zero", len(weights))
+	}
+	amount = nonNegative(amount)
+	allocations := make([]Allocation, len(validators))
+	for idx, validator := range validators {
+		share := new(big.Rat).SetInt64(amount)
+		share.Mul(share, weights[idx]).Quo(share, total)
+		allocations[idx] = Allocation{
+			ValidatorAddress: validator.ValidatorAddress,
+			Amount:           new(big.Int).Quo(share.Num(), share.Denom()).Int64(),
+		}
+	}
+	return allocations, nil
+}
+
+func nonNegative(amount int64) int64 {
+	if amount < 0 {
+		return 0
+	}
+	return amount
+}
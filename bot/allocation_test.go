@@ -0,0 +1,134 @@
+package bot_test
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/bot"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func valoper(t *testing.T, prefix string, b byte) string {
+	bz := make([]byte, 20)
+	bz[0] = b
+	address, err := bech32.ConvertAndEncode(prefix+"valoper", bz)
+	require.NoError(t, err)
+	return address
+}
+
+func TestAllocators(t *testing.T) {
+	a, b, c := valoper(t, "cosmos", 1), valoper(t, "cosmos", 2), valoper(t, "cosmos", 3)
+	validators := []bot.ValidatorShare{
+		{ValidatorAddress: a, Rewards: 100, Delegated: 1000},
+		{ValidatorAddress: b, Rewards: 300, Delegated: 1000},
+		{ValidatorAddress: c, Rewards: 0, Delegated: 2000},
+	}
+
+	testCases := []struct {
+		name      string
+		allocator bot.Allocator
+		expected  []bot.Allocation
+	}{
+		{
+			name:      "rewards",
+			allocator: bot.RewardsAllocator{},
+			expected:  []bot.Allocation{{a, 250}, {b, 750}, {c, 0}},
+		},
+		{
+			name:      "delegation",
+			allocator: bot.DelegationAllocator{},
+			expected:  []bot.Allocation{{a, 250}, {b, 250}, {c, 500}},
+		},
+		{
+			name:      "equal",
+			allocator: bot.EqualAllocator{},
+			expected:  []bot.Allocation{{a, 333}, {b, 333}, {c, 333}},
+		},
+		{
+			name:      "single validator",
+			allocator: bot.SingleValidatorAllocator{Validator: c},
+			expected:  []bot.Allocation{{c, 1000}},
+		},
+		{
+			name: "weights",
+			allocator: bot.WeightsAllocator{Weights: []*types.ValidatorWeight{
+				{ValidatorAddress: c, Weight: 0.7},
+				{ValidatorAddress: a, Weight: 0.3},
+			}},
+			expected: []bot.Allocation{{c, 700}, {a, 300}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			allocations, err := tc.allocator.Allocate(1000, validators)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, allocations)
+
+			// nothing is delegated when the balance is below the tolerance
+			allocations, err = tc.allocator.Allocate(-10, validators)
+			require.NoError(t, err)
+			for _, allocation := range allocations {
+				require.Zero(t, allocation.Amount)
+			}
+		})
+	}
+
+	// proportional strategies can't allocate without anything to be proportional to
+	_, err := bot.RewardsAllocator{}.Allocate(1000, []bot.ValidatorShare{{ValidatorAddress: a}})
+	require.Error(t, err)
+	_, err = bot.DelegationAllocator{}.Allocate(1000, nil)
+	require.Error(t, err)
+
+	// without any delegations left the delegation strategy falls back to the rewards
+	allocations, err := bot.DelegationAllocator{}.Allocate(1000, []bot.ValidatorShare{
+		{ValidatorAddress: a, Rewards: 100},
+		{ValidatorAddress: b, Rewards: 300},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bot.Allocation{{a, 250}, {b, 750}}, allocations)
+
+	// large amounts don't overflow
+	allocations, err = bot.RewardsAllocator{}.Allocate(1<<62, []bot.ValidatorShare{
+		{ValidatorAddress: a, Rewards: 1 << 40},
+		{ValidatorAddress: b, Rewards: 1 << 40},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []bot.Allocation{{a, 1 << 61}, {b, 1 << 61}}, allocations)
+}
+
+func TestNewAllocator(t *testing.T) {
+	chain := types.DefaultChains()[0]
+	validator := valoper(t, "cosmos", 1)
+
+	allocator, err := bot.NewAllocator(chain, &types.Record{})
+	require.NoError(t, err)
+	require.Equal(t, bot.RewardsAllocator{}, allocator)
+
+	allocator, err = bot.NewAllocator(chain, &types.Record{
+		Allocation:          types.AllocationStrategy_SINGLE_VALIDATOR,
+		AllocationValidator: validator,
+	})
+	require.NoError(t, err)
+	require.Equal(t, bot.SingleValidatorAllocator{Validator: validator}, allocator)
+
+	invalid := []*types.Record{
+		{Allocation: types.AllocationStrategy_SINGLE_VALIDATOR},
+		{Allocation: types.AllocationStrategy_SINGLE_VALIDATOR, AllocationValidator: valoper(t, "osmo", 1)},
+		{Allocation: types.AllocationStrategy_SINGLE_VALIDATOR, AllocationValidator: "cosmosvaloper1invalid"},
+		{Allocation: types.AllocationStrategy_TARGET_WEIGHTS},
+		{Allocation: types.AllocationStrategy_TARGET_WEIGHTS, AllocationWeights: []*types.ValidatorWeight{
+			{ValidatorAddress: validator, Weight: 0},
+		}},
+		{Allocation: types.AllocationStrategy_TARGET_WEIGHTS, AllocationWeights: []*types.ValidatorWeight{
+			{ValidatorAddress: validator, Weight: 1},
+			{ValidatorAddress: validator, Weight: 2},
+		}},
+		{Allocation: types.AllocationStrategy(99)},
+	}
+	for _, record := range invalid {
+		_, err := bot.NewAllocator(chain, record)
+		require.Error(t, err, record.String())
+	}
+}
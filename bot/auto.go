@@ -0,0 +1,225 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distribution "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	mint "github.com/cosmos/cosmos-sdk/x/mint/types"
+	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+const (
+	// maxAutoInterval bounds the interval computed for small delegations
+	maxAutoInterval = 30 * 24 * time.Hour
+	// minAutoInterval is used on chains without a minimum interval
+	minAutoInterval = time.Hour
+	year            = 365 * 24 * time.Hour
+)
+
+// CompoundingParams are the inputs to the optimal restake interval of a delegator. Rates
+// are fractions, amounts are in the native denom of the chain.
+type CompoundingParams struct {
+	Inflation    float64
+	CommunityTax float64
+	BondedRatio  float64
+	// Commission is the average commission of the validators weighted by delegation
+	Commission float64
+	Delegated  int64
+	Fee        int64
+}
+
+// APR is the yearly staking reward rate of the delegator before compounding
+func (p CompoundingParams) APR() float64 {
+	if p.BondedRatio <= 0 {
+		return 0
+	}
+	return p.Inflation * (1 - p.CommunityTax) / p.BondedRatio * (1 - p.Commission)
+}
+
+// OptimalInterval returns the restake interval that maximizes the yield after fees.
+// Restaking every t years grows the delegation by about r*t - f/P per restake, so the
+// yearly rate after fees is roughly r - f/(P*t) - r²t/2, which peaks at
+// t = sqrt(2f / (P*r²)). The interval is rounded to the hour and kept between min and
+// a month. Without rewards it is a month.
+func OptimalInterval(params CompoundingParams, min time.Duration) time.Duration {
+	if min <= 0 {
+		min = minAutoInterval
+	}
+	apr := params.APR()
+	if apr <= 0 || params.Delegated <= 0 {
+		return maxAutoInterval
+	}
+	years := math.Sqrt(2 * float64(params.Fee) / (float64(params.Delegated) * apr * apr))
+	interval := time.Duration(years * float64(year)).Round(time.Hour)
+	switch {
+	case interval < min:
+		return min
+	case interval > maxAutoInterval:
+		return maxAutoInterval
+	default:
+		return interval
+	}
+}
+
+// QueryCompoundingParams queries the staking parameters of the chain and the
+// delegations of the address that determine its optimal restake interval
+func QueryCompoundingParams(ctx context.Context, conn *grpc.ClientConn, chain types.Chain, address string) (CompoundingParams, error) {
+	params := CompoundingParams{Fee: chain.RestakeFee}
+
+	inflation, err := mint.NewQueryClient(conn).Inflation(ctx, &mint.QueryInflationRequest{})
+	if err != nil {
+		return params, fmt.Errorf("querying inflation: %w", err)
+	}
+	params.Inflation = decToFloat(inflation.Inflation)
+
+	distributionParams, err := distribution.NewQueryClient(conn).Params(ctx, &distribution.QueryParamsRequest{})
+	if err != nil {
+		return params, fmt.Errorf("querying distribution params: %w", err)
+	}
+	params.CommunityTax = decToFloat(distributionParams.Params.CommunityTax)
+
+	stakingClient := staking.NewQueryClient(conn)
+	pool, err := stakingClient.Pool(ctx, &staking.QueryPoolRequest{})
+	if err != nil {
+		return params, fmt.Errorf("querying staking pool: %w", err)
+	}
+	supply, err := bank.NewQueryClient(conn).SupplyOf(ctx, &bank.QuerySupplyOfRequest{Denom: chain.NativeDenom})
+	if err != nil {
+		return params, fmt.Errorf("querying supply: %w", err)
+	}
+	if supply.Amount.Amount.IsPositive() {
+		params.BondedRatio = decToFloat(pool.Pool.BondedTokens.ToDec().Quo(supply.Amount.Amount.ToDec()))
+	}
+
+	delegations, err := stakingClient.DelegatorDelegations(ctx, &staking.QueryDelegatorDelegationsRequest{DelegatorAddr: address})
+	if err != nil {
+		return params, fmt.Errorf("querying delegations: %w", err)
+	}
+	commission := sdk.ZeroDec()
+	delegated := sdk.ZeroInt()
+	for _, delegation := range delegations.DelegationResponses {
+		amount := delegation.Balance.Amount
+		if delegation.Balance.Denom != chain.NativeDenom || !amount.IsPositive() {
+			continue
+		}
+		validator, err := stakingClient.Validator(ctx, &staking.QueryValidatorRequest{ValidatorAddr: delegation.Delegation.ValidatorAddress})
+		if err != nil {
+			return params, fmt.Errorf("querying validator %s: %w", delegation.Delegation.ValidatorAddress, err)
+		}
+		commission = commission.Add(validator.Validator.Commission.Rate.MulInt(amount))
+		delegated = delegated.Add(amount)
+	}
+	if delegated.IsPositive() {
+		params.Commission = decToFloat(commission.QuoInt(delegated))
+		params.Delegated = delegated.Int64()
+	}
+	return params, nil
+}
+
+// AutoInterval computes the optimal restake interval of an address on a chain
+func (bot AutoStakeBot) AutoInterval(ctx context.Context, chain types.Chain, address string) (time.Duration, error) {
+	conn, err := grpc.Dial(chain.GRPC, grpc.WithInsecure())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	params, err := QueryCompoundingParams(ctx, conn, chain, address)
+	if err != nil {
+		return 0, err
+	}
+	interval := OptimalInterval(params, chain.MinInterval.Duration)
+	log.Debug().
+		Str("address", address).
+		Str("chain", chain.Id).
+		Float64("apr", params.APR()).
+		Int64("delegated", params.Delegated).
+		Dur("interval", interval).
+		Msg("Computed optimal restake interval")
+	return interval, nil
+}
+
+// evaluateAutoIntervals computes the interval of every AUTO record that has not been
+// evaluated within the configured period and reschedules it
+func (bot AutoStakeBot) evaluateAutoIntervals() {
+	records, _, err := bot.Store.List(store.Filter{Frequency: types.Frequency_AUTO})
+	if err != nil {
+		log.Error().Err(err).Msg("Listing records with automatic frequency")
+		return
+	}
+	stale := time.Now().Add(-bot.config.AutoEvaluateInterval.Duration).Unix()
+	for _, record := range records {
+		if record.AutoEvaluatedUnixTime > stale {
+			continue
+		}
+		select {
+		case <-bot.stop:
+			return
+		default:
+		}
+		if err := bot.evaluateAutoInterval(record); err != nil {
+			log.Error().Err(err).Str("address", record.Address).Str("chain", record.ChainId).Msg("Computing restake interval")
+		}
+	}
+}
+
+func (bot AutoStakeBot) evaluateAutoInterval(record *types.Record) error {
+	chain, err := bot.chains.FindChainById(record.ChainId)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := bot.RestakeContext()
+	defer cancel()
+	interval, evalErr := bot.AutoInterval(ctx, chain, record.Address)
+	var updated *types.Record
+	err = bot.Store.Update(record.ChainId, record.Address, func(r *types.Record) error {
+		if evalErr != nil {
+			// keep the previous interval and try again after the next period
+			r.AutoEvaluatedUnixTime = time.Now().Unix()
+			return nil
+		}
+		r.SetAutoInterval(interval, time.Now())
+		updated = r
+		return nil
+	})
+	if evalErr != nil {
+		return evalErr
+	}
+	if err != nil {
+		return err
+	}
+	return bot.Schedule(updated)
+}
+
+// evaluateLoop periodically recomputes the intervals of AUTO records until the bot is
+// stopped
+func (bot AutoStakeBot) evaluateLoop() {
+	defer close(bot.evaluated)
+	ticker := time.NewTicker(resyncInterval)
+	defer ticker.Stop()
+	for {
+		bot.evaluateAutoIntervals()
+		select {
+		case <-bot.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func decToFloat(dec sdk.Dec) float64 {
+	f, err := dec.Float64()
+	if err != nil {
+		return 0
+	}
+	return f
+}
@@ -0,0 +1,38 @@
+package bot_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/bot"
+)
+
+func TestOptimalInterval(t *testing.T) {
+	params := bot.CompoundingParams{
+		Inflation:    0.1,
+		CommunityTax: 0.02,
+		BondedRatio:  0.65,
+		Commission:   0.05,
+		Delegated:    1000000000, // 1000 atom
+		Fee:          5000,
+	}
+	require.InDelta(t, 0.1432, params.APR(), 0.0001)
+	// sqrt(2 * 5000 / (1e9 * 0.1432²)) years is a little over 8 days
+	require.Equal(t, 193*time.Hour, bot.OptimalInterval(params, time.Hour))
+
+	// larger delegations restake more often
+	params.Delegated *= 100
+	require.Equal(t, 19*time.Hour, bot.OptimalInterval(params, time.Hour))
+
+	// but not more often than the minimum interval of the chain
+	params.Delegated *= 100
+	require.Equal(t, 6*time.Hour, bot.OptimalInterval(params, 6*time.Hour))
+	require.Equal(t, 2*time.Hour, bot.OptimalInterval(params, 0))
+
+	// small delegations and chains without rewards restake monthly
+	params.Delegated = 1000
+	require.Equal(t, 30*24*time.Hour, bot.OptimalInterval(params, time.Hour))
+	require.Equal(t, 30*24*time.Hour, bot.OptimalInterval(bot.CompoundingParams{Delegated: 1000000, Fee: 5000}, time.Hour))
+}
@@ -3,13 +3,12 @@ package bot
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
-	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/types/bech32"
-	cron "github.com/robfig/cron/v3"
 	"github.com/rs/zerolog/log"
 
 	"github.com/plural-labs/stakebot/client"
@@ -17,21 +16,45 @@ import (
 	"github.com/plural-labs/stakebot/types"
 )
 
+// resyncInterval is how often the scheduler picks up records added or removed without
+// going through the bot, e.g. by an import
+const resyncInterval = 5 * time.Minute
+
 type AutoStakeBot struct {
-	Store *store.Store
+	Store store.Store
 
-	chains  types.ChainRegistry
-	cron    *cron.Cron
-	client  *client.Client
-	address string
+	chains    types.ChainRegistry
+	scheduler *scheduler
+	client    *client.Client
+	address   string
+	// stop is closed by StopJobs, done and evaluated once the scheduler and the
+	// evaluation of automatic intervals returned
+	stop      chan struct{}
+	done      chan struct{}
+	evaluated chan struct{}
+	limits    *limits
+	config    types.BotConfig
+	// ctx is the parent of all restakes and is cancelled by StopJobs
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func New(homeDir string, key keyring.Keyring, chains []types.Chain) (*AutoStakeBot, error) {
-	store, err := store.New(homeDir)
-	if err != nil {
-		return nil, err
+func New(store store.Store, key keyring.Keyring, chains []types.Chain, cfg types.BotConfig) (*AutoStakeBot, error) {
+	if cfg.Workers <= 0 {
+		return nil, fmt.Errorf("workers must be positive, got %d", cfg.Workers)
+	}
+	if cfg.RestakeTimeout.Duration <= 0 {
+		return nil, fmt.Errorf("restake timeout must be positive, got %s", cfg.RestakeTimeout.Duration)
+	}
+	if cfg.Retries < 0 || cfg.RetryBackoff.Duration < 0 {
+		return nil, fmt.Errorf("retries and retry backoff must not be negative")
+	}
+	if cfg.AutoEvaluateInterval.Duration <= 0 {
+		return nil, fmt.Errorf("auto evaluate interval must be positive, got %s", cfg.AutoEvaluateInterval.Duration)
+	}
+	if cfg.CatchUpLimit < 0 {
+		return nil, fmt.Errorf("catch up limit must not be negative, got %d", cfg.CatchUpLimit)
 	}
-
 	keys, err := key.List()
 	if err != nil {
 		return nil, err
@@ -47,51 +70,217 @@ func New(homeDir string, key keyring.Keyring, chains []types.Chain) (*AutoStakeB
 	}
 	hexAddress := hex.EncodeToString(bz)
 	client := client.New(key, chains)
+	ctx, cancel := context.WithCancel(context.Background())
 
 	return &AutoStakeBot{
-		chains:  chains,
-		Store:   store,
-		cron:    cron.New(),
-		client:  client,
-		address: hexAddress,
+		chains:    chains,
+		Store:     store,
+		scheduler: newScheduler(cfg.CatchUpLimit),
+		client:    client,
+		address:   hexAddress,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		evaluated: make(chan struct{}),
+		limits:    newLimits(cfg.Workers, chains),
+
+		config: cfg,
+		ctx:    ctx,
+		cancel: cancel,
 	}, nil
 }
 
+// StartJobs schedules every record in the store and starts restaking them as they
+// become due.
 func (bot AutoStakeBot) StartJobs() error {
-	cronStrings := map[int32]string{
-		1: "@hourly",
-		2: "@every 6h", // quarter day
-		3: "@daily",
-		4: "@weekly",
-		5: "@monthly",
+	if err := bot.resync(); err != nil {
+		return err
 	}
+	go bot.loop()
+	go bot.evaluateLoop()
+	log.Info().
+		Int("records", bot.scheduler.len()).
+		Int("overdue", bot.scheduler.catchUps()).
+		Msg("Started scheduler")
+	return nil
+}
 
-	// create a cron job for each frequency
-	for frequency := int32(1); frequency <= 5; frequency++ {
-		id, err := bot.cron.AddFunc(cronStrings[frequency], bot.Job(frequency))
-		if err != nil {
-			return err
-		}
+// StopJobs stops the scheduler, cancels the restakes in progress and waits for them to
+// return
+func (bot AutoStakeBot) StopJobs() {
+	close(bot.stop)
+	bot.cancel()
+	<-bot.done
+	<-bot.evaluated
+}
+
+// Cancel cancels all restakes in progress. It is used to stop a bot that runs without
+// StartJobs, otherwise StopJobs cancels them.
+func (bot AutoStakeBot) Cancel() {
+	bot.cancel()
+}
+
+// RestakeContext returns the context a single restake runs with. It is cancelled once
+// the restake timeout passes or the bot is stopped.
+func (bot AutoStakeBot) RestakeContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(bot.ctx, bot.config.RestakeTimeout.Duration)
+}
+
+// Schedule starts scheduling a new record or picks up the changed schedule of an
+// existing one. The schedule is expected to have been validated against the minimum
+// interval of the chain on registration.
+func (bot AutoStakeBot) Schedule(record *types.Record) error {
+	chain, err := bot.chains.FindChainById(record.ChainId)
+	if err != nil {
+		return err
+	}
+	if bot.scheduler.scheduled(record) {
+		return nil
+	}
+	schedule, err := chain.Schedule(record)
+	if err != nil {
+		return err
+	}
+	bot.scheduler.set(record, schedule, time.Now())
+	return nil
+}
 
-		log.Debug().Str("frequency", types.Frequency_name[frequency]).Str("cron string", cronStrings[frequency]).Int64("Id", int64(id)).Msg("Scheduled cron job")
+// Register saves the settings of a new registration. If the address is already
+// registered only its settings are replaced, keeping its history, totals, suspension
+// and pause which can't be lifted by registering again. The stored record is returned.
+func (bot AutoStakeBot) Register(registration *types.Record) (*types.Record, error) {
+	var record *types.Record
+	err := bot.Store.Update(registration.ChainId, registration.Address, func(r *types.Record) error {
+		r.Frequency = registration.Frequency
+		r.Tolerance = registration.Tolerance
+		r.Schedule = registration.Schedule
+		r.MinRewards = registration.MinRewards
+		r.MinRewardsFeeMultiple = registration.MinRewardsFeeMultiple
+		r.Allocation = registration.Allocation
+		r.AllocationValidator = registration.AllocationValidator
+		r.AllocationWeights = registration.AllocationWeights
+		r.UnhealthyPolicy = registration.UnhealthyPolicy
+		r.Type = registration.Type
+		// an interval that failed to be computed leaves the previous one
+		if registration.AutoIntervalSeconds > 0 {
+			r.AutoIntervalSeconds = registration.AutoIntervalSeconds
+			r.AutoEvaluatedUnixTime = registration.AutoEvaluatedUnixTime
+		}
+		record = r
+		return nil
+	})
+	if errors.Is(err, store.ErrNotFound) {
+		return registration, bot.Store.SetRecord(registration)
+	}
+	if err != nil {
+		return nil, err
 	}
+	return record, nil
+}
 
-	// start up the scheduler
-	bot.cron.Start()
+// loop waits for the next record to become due and restakes all due records until the
+// bot is stopped. The store is periodically resynced to pick up changes made by others.
+func (bot AutoStakeBot) loop() {
+	defer close(bot.done)
+	resync := time.NewTicker(resyncInterval)
+	defer resync.Stop()
+	for {
+		wait := resyncInterval
+		if next, ok := bot.scheduler.nextRun(); ok {
+			wait = time.Until(next)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-bot.stop:
+			timer.Stop()
+			return
+		case <-bot.scheduler.wake:
+		case <-resync.C:
+			if err := bot.resync(); err != nil {
+				log.Error().Err(err).Msg("Resyncing schedules")
+			}
+		case <-timer.C:
+			bot.runDue(time.Now())
+		}
+		timer.Stop()
+	}
+}
 
-	records, err := bot.Store.Len()
+// resync schedules all records of the store and drops records that have been removed
+func (bot AutoStakeBot) resync() error {
+	records, _, err := bot.Store.List(store.Filter{})
 	if err != nil {
 		return err
 	}
-	log.Info().Int("records", records).Msg("Started cron scheduler")
+	removed := bot.scheduler.entries()
+	for _, record := range records {
+		delete(removed, scheduleKey(record.ChainId, record.Address))
+		if err := bot.Schedule(record); err != nil {
+			log.Error().Err(err).Str("address", record.Address).Str("chain", record.ChainId).Msg("Scheduling record")
+		}
+	}
+	for _, entry := range removed {
+		bot.scheduler.remove(entry.chainID, entry.address)
+	}
 	return nil
 }
 
-func (bot AutoStakeBot) StopJobs() {
-	ctx := bot.cron.Stop()
+// runDue restakes all records that are due and schedules their next run
+func (bot AutoStakeBot) runDue(now time.Time) {
+	entries := bot.scheduler.due(now)
+	paused, err := bot.pausedChains()
+	if err != nil {
+		// without knowing which chains are paused nothing is restaked
+		log.Error().Err(err).Msg("Reading paused chains")
+		for _, entry := range entries {
+			bot.scheduler.reschedule(entry, now)
+		}
+		return
+	}
+	records := make([]*types.Record, 0, len(entries))
+	for _, entry := range entries {
+		// the record is read again as it may have changed since it was scheduled
+		record, err := bot.Store.GetRecord(entry.chainID, entry.address)
+		if errors.Is(err, store.ErrNotFound) {
+			bot.scheduler.remove(entry.chainID, entry.address)
+			continue
+		}
+		if err != nil {
+			log.Error().Err(err).Str("address", entry.address).Msg("Reading record")
+			bot.scheduler.reschedule(entry, now)
+			continue
+		}
+		if !runnable(record, paused) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	if len(records) > 0 {
+		bot.Run(records)
+	}
+
+	done := time.Now()
+	for _, entry := range entries {
+		bot.scheduler.reschedule(entry, done)
+	}
+}
 
-	// wait for all scheduled jobs to terminate
-	<-ctx.Done()
+// runnable reports whether a due record is restaked, that is neither the record nor its
+// chain are paused and the record is not suspended
+func runnable(record *types.Record, pausedChains map[string]bool) bool {
+	var reason string
+	switch {
+	case pausedChains[record.ChainId]:
+		reason = "chain paused"
+	case record.Paused:
+		reason = "paused"
+	case record.Suspended:
+		reason = "suspended"
+	default:
+		return true
+	}
+	log.Debug().Str("address", record.Address).Str("chain", record.ChainId).Str("reason", reason).Msg("Skipping record")
+	return false
 }
 
 func (bot AutoStakeBot) Chains() types.ChainRegistry {
@@ -118,38 +307,118 @@ func (bot AutoStakeBot) Bech32Address(chainID string) (string, error) {
 	return bech32Address, nil
 }
 
-func (bot AutoStakeBot) Job(frequency int32) func() {
-	return func() {
-		log.Info().Int32("frequency", frequency).Msg("Starting cron job")
-		// We don't cache these as records may have been removed or added between cron jobs
-		records, err := bot.Store.GetRecordsByFrequency(frequency)
-		if err != nil {
-			log.Error().Err(err).Str("frequency", types.Frequency_name[frequency]).Msg("Retrieveing records")
+// SaveResult updates the record of an address with the outcome of a restake. Successful
+// restakes add to the total rewards and the amounts of each validator and resume a
+// suspended record. Failures are added to the record's errors and suspend the record
+// once it failed too many times in a row, except in dry run mode where nothing was
+// broadcast and only the last run is recorded.
+func (bot AutoStakeBot) SaveResult(event *types.Event, restakeErr error) error {
+	now := time.Now().Unix()
+	var (
+		// suspended and failures are reset on every attempt as fn may run more than once
+		suspended bool
+		failures  uint32
+	)
+	err := bot.Store.Update(event.ChainId, event.Address, func(r *types.Record) error {
+		suspended, failures = false, 0
+		r.LastRun = &types.LastRun{
+			UnixTime:   now,
+			Outcome:    event.Outcome,
+			DurationMs: event.DurationMs,
+			ErrorCode:  event.ErrorCode,
 		}
-
-		for _, record := range records {
-			chain, err := bot.chains.FindChainFromAddress(record.Address)
-			if err != nil {
-				log.Error().Err(err).Str("address", record.Address).Msg("Finding chain")
+		if restakeErr != nil {
+			if bot.config.DryRun {
+				return nil
 			}
+			suspended = r.AddFailure(&types.ErrorEntry{
+				UnixTime: now,
+				Code:     ClassifyError(restakeErr),
+				Message:  restakeErr.Error(),
+			}, bot.config.SuspendAfter)
+			failures = r.ConsecutiveFailures
+			return nil
+		}
+		if event.Outcome == types.Outcome_SIMULATED {
+			// nothing was restaked
+			return nil
+		}
+		if event.Outcome == types.Outcome_SUCCESS {
+			r.TotalAutostakedRewards += event.TotalRewards
+			r.TotalAutostakedCommission += event.Commission
+			r.AddRestake(event.Validators)
+		}
+		r.Unsuspend()
+		r.LastUpdatedUnixTime = now
+		return nil
+	})
+	if err == nil && suspended {
+		log.Warn().
+			Str("address", event.Address).
+			Str("chain", event.ChainId).
+			Uint32("failures", failures).
+			Msg("Suspending record")
+	}
+	return err
+}
 
-			// TODO: consider using a timeout so we don't get stuck on a single user
-			rewards, err := bot.Restake(context.TODO(), record.Address, record.Tolerance, sdk.NewInt64Coin(chain.NativeDenom, chain.RestakeFee))
-			if err != nil {
-				log.Error().Err(err).Str("address", record.Address).Msg("Restaking")
-				record.ErrorLogs = err.Error()
-				continue
-			}
-			record.TotalAutostakedRewards += rewards
-			record.LastUpdatedUnixTime = time.Now().Unix()
-			record.ErrorLogs = ""
+// NextRun returns when the record is restaked next. It reports false if the record is
+// not scheduled or is being restaked right now.
+func (bot AutoStakeBot) NextRun(chainID, address string) (time.Time, bool) {
+	return bot.scheduler.nextRunOf(chainID, address)
+}
 
-			err = bot.Store.SetRecord(record)
-			if err != nil {
-				log.Error().Err(err).Str("address", record.Address).Msg("Saving record")
-			}
+// PauseRecord pauses or resumes restaking a single record. Paused records keep their
+// settings and history but are skipped when they are due.
+func (bot AutoStakeBot) PauseRecord(chainID, address string, paused bool) (*types.Record, error) {
+	var record *types.Record
+	err := bot.Store.Update(chainID, address, func(r *types.Record) error {
+		r.Paused = paused
+		record = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
 
-		}
-		log.Info().Int("records", len(records)).Str("frequency", types.Frequency_name[frequency]).Int32("freq", frequency).Msg("Completed cron job")
+// PauseChain pauses or resumes restaking all records of a chain
+func (bot AutoStakeBot) PauseChain(chainID string, paused bool) error {
+	if _, err := bot.chains.FindChainById(chainID); err != nil {
+		return err
+	}
+	return bot.Store.SetChainPaused(chainID, paused)
+}
+
+// ChainPaused reports whether the records of a chain are paused
+func (bot AutoStakeBot) ChainPaused(chainID string) (bool, error) {
+	paused, err := bot.pausedChains()
+	return paused[chainID], err
+}
+
+func (bot AutoStakeBot) pausedChains() (map[string]bool, error) {
+	chains, err := bot.Store.PausedChains()
+	if err != nil {
+		return nil, err
+	}
+	paused := make(map[string]bool, len(chains))
+	for _, chainID := range chains {
+		paused[chainID] = true
+	}
+	return paused, nil
+}
+
+// Unsuspend resumes scheduling a suspended record
+func (bot AutoStakeBot) Unsuspend(chainID, address string) (*types.Record, error) {
+	var record *types.Record
+	err := bot.Store.Update(chainID, address, func(r *types.Record) error {
+		r.Unsuspend()
+		record = r
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return record, nil
 }
@@ -0,0 +1,75 @@
+package bot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestRegister(t *testing.T) {
+	db := store.NewMemory()
+	bot := AutoStakeBot{Store: db}
+
+	record, err := bot.Register(&types.Record{ChainId: "test-1", Address: "addr", Frequency: types.Frequency_DAILY, Tolerance: 10})
+	require.NoError(t, err)
+	require.Equal(t, types.Frequency_DAILY, record.Frequency)
+
+	require.NoError(t, db.Update("test-1", "addr", func(r *types.Record) error {
+		r.Suspended = true
+		r.ConsecutiveFailures = 5
+		r.Paused = true
+		r.TotalAutostakedRewards = 100
+		r.LastUpdatedUnixTime = 1
+		r.AddError(&types.ErrorEntry{UnixTime: 2, Code: types.ErrorCode_GRANT_MISSING})
+		r.AddRestake([]*types.ValidatorRestake{{ValidatorAddress: "val", Claimed: 100, Delegated: 90}})
+		return nil
+	}))
+
+	// registering again changes the settings but neither lifts the suspension and pause
+	// nor resets the history of the record
+	minRewards := int64(0)
+	record, err = bot.Register(&types.Record{ChainId: "test-1", Address: "addr", Frequency: types.Frequency_WEEKLY, Tolerance: 20, MinRewards: &minRewards})
+	require.NoError(t, err)
+	stored, err := db.GetRecord("test-1", "addr")
+	require.NoError(t, err)
+	require.Equal(t, record.String(), stored.String())
+	require.Equal(t, types.Frequency_WEEKLY, stored.Frequency)
+	require.EqualValues(t, 20, stored.Tolerance)
+	require.Equal(t, int64(0), stored.GetMinRewards())
+	require.NotNil(t, stored.MinRewards)
+	require.True(t, stored.Suspended)
+	require.True(t, stored.Paused)
+	require.EqualValues(t, 5, stored.ConsecutiveFailures)
+	require.EqualValues(t, 100, stored.TotalAutostakedRewards)
+	require.EqualValues(t, 1, stored.LastUpdatedUnixTime)
+	require.Len(t, stored.Errors, 1)
+	require.Len(t, stored.Validators, 1)
+}
+
+func TestSaveResultDryRun(t *testing.T) {
+	db := store.NewMemory()
+	require.NoError(t, db.SetRecord(&types.Record{ChainId: "test-1", Address: "addr"}))
+	event := &types.Event{ChainId: "test-1", Address: "addr", Outcome: types.Outcome_FAILURE}
+	failure := errors.New("simulation failed")
+
+	// failed simulations don't count towards suspending the record
+	bot := AutoStakeBot{Store: db, config: types.BotConfig{SuspendAfter: 1, DryRun: true}}
+	require.NoError(t, bot.SaveResult(event, failure))
+	record, err := db.GetRecord("test-1", "addr")
+	require.NoError(t, err)
+	require.False(t, record.Suspended)
+	require.Zero(t, record.ConsecutiveFailures)
+	require.Empty(t, record.Errors)
+	require.Equal(t, types.Outcome_FAILURE, record.LastRun.Outcome)
+
+	bot.config.DryRun = false
+	require.NoError(t, bot.SaveResult(event, failure))
+	record, err = db.GetRecord("test-1", "addr")
+	require.NoError(t, err)
+	require.True(t, record.Suspended)
+	require.Len(t, record.Errors, 1)
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/plural-labs/stakebot/client"
+	"github.com/plural-labs/stakebot/types"
+)
+
+// errorPatterns match the messages of the sdk errors returned by nodes, either as a
+// gRPC error or in the raw log of a failed transaction. The first match wins.
+var errorPatterns = []struct {
+	code     types.ErrorCode
+	patterns []string
+}{
+	{types.ErrorCode_FEEGRANT_EXHAUSTED, []string{
+		"fee-grant not found",
+		"fee allowance not found",
+		"fee limit exceeded",
+		"fee allowance expired",
+		"not covering the fees",
+		"does not cover authz.msgexec fees",
+	}},
+	{types.ErrorCode_GRANT_MISSING, []string{
+		"authorization not found",
+		"must authorize the stakebot",
+	}},
+	{types.ErrorCode_SEQUENCE_MISMATCH, []string{
+		"incorrect account sequence",
+		"account sequence mismatch",
+	}},
+	{types.ErrorCode_INSUFFICIENT_FEE, []string{
+		"insufficient fee",
+	}},
+	{types.ErrorCode_NODE_UNREACHABLE, []string{
+		"connection refused",
+		"no such host",
+		"error reading server preface",
+	}},
+}
+
+// ClassifyError maps the error of a failed restake to an error code. Errors that are not
+// recognized are UNCLASSIFIED.
+func ClassifyError(err error) types.ErrorCode {
+	if err == nil {
+		return types.ErrorCode_UNCLASSIFIED
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, class := range errorPatterns {
+		for _, pattern := range class.patterns {
+			if strings.Contains(msg, pattern) {
+				return class.code
+			}
+		}
+	}
+
+	if errors.Is(err, client.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return types.ErrorCode_TIMEOUT
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return types.ErrorCode_NODE_UNREACHABLE
+	}
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if errors.As(err, &grpcErr) {
+		switch grpcErr.GRPCStatus().Code() {
+		case codes.Unavailable:
+			return types.ErrorCode_NODE_UNREACHABLE
+		case codes.DeadlineExceeded:
+			return types.ErrorCode_TIMEOUT
+		}
+	}
+	return types.ErrorCode_UNCLASSIFIED
+}
+
+// Retryable reports whether a restake that failed with the code may succeed when
+// attempted again shortly after
+func Retryable(code types.ErrorCode) bool {
+	switch code {
+	case types.ErrorCode_NODE_UNREACHABLE, types.ErrorCode_SEQUENCE_MISMATCH:
+		return true
+	default:
+		return false
+	}
+}
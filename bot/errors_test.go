@@ -0,0 +1,59 @@
+package bot_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/plural-labs/stakebot/bot"
+	"github.com/plural-labs/stakebot/client"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestClassifyError(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code types.ErrorCode
+	}{
+		{nil, types.ErrorCode_UNCLASSIFIED},
+		{errors.New("something went wrong"), types.ErrorCode_UNCLASSIFIED},
+		{
+			fmt.Errorf("failed to submit restake transaction: %v", sdkerrors.ErrUnauthorized.Wrap("authorization not found")),
+			types.ErrorCode_GRANT_MISSING,
+		},
+		{
+			fmt.Errorf("failed to submit restake transaction: %v", sdkerrors.Wrap(sdkerrors.ErrUnauthorized, "fee-grant not found")),
+			types.ErrorCode_FEEGRANT_EXHAUSTED,
+		},
+		{
+			fmt.Errorf("error sending messages: %w", sdkerrors.Wrap(feegrant.ErrFeeLimitExceeded, "basic allowance")),
+			types.ErrorCode_FEEGRANT_EXHAUSTED,
+		},
+		{
+			fmt.Errorf("error sending messages: %w", sdkerrors.Wrap(sdkerrors.ErrInsufficientFee, "insufficient fees; got: 10uatom required: 500uatom")),
+			types.ErrorCode_INSUFFICIENT_FEE,
+		},
+		{
+			fmt.Errorf("error sending messages: %w", sdkerrors.Wrap(sdkerrors.ErrWrongSequence, "account sequence mismatch, expected 5, got 4")),
+			types.ErrorCode_SEQUENCE_MISMATCH,
+		},
+		{
+			status.Error(codes.Unavailable, "connection error: desc = \"transport: Error while dialing dial tcp 127.0.0.1:9090: connect: connection refused\""),
+			types.ErrorCode_NODE_UNREACHABLE,
+		},
+		{fmt.Errorf("query: %w", status.Error(codes.Unavailable, "")), types.ErrorCode_NODE_UNREACHABLE},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), types.ErrorCode_TIMEOUT},
+		{fmt.Errorf("error sending messages: %w", client.ErrTimeout), types.ErrorCode_TIMEOUT},
+		{status.Error(codes.DeadlineExceeded, "context deadline exceeded"), types.ErrorCode_TIMEOUT},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.code, bot.ClassifyError(tc.err), "%v", tc.err)
+	}
+}
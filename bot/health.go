@@ -0,0 +1,148 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
+	"github.com/cosmos/cosmos-sdk/std"
+	slashing "github.com/cosmos/cosmos-sdk/x/slashing/types"
+	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+// interfaceRegistry unpacks the consensus keys of validators
+var interfaceRegistry = func() codectypes.InterfaceRegistry {
+	registry := codectypes.NewInterfaceRegistry()
+	std.RegisterInterfaces(registry)
+	return registry
+}()
+
+// queryValidatorHealth queries the status of each validator. The signing info of jailed
+// validators is queried to tell whether they are tombstoned.
+func queryValidatorHealth(ctx context.Context, conn *grpc.ClientConn, addresses []string) (map[string]types.ValidatorHealth, error) {
+	stakingClient := staking.NewQueryClient(conn)
+	slashingClient := slashing.NewQueryClient(conn)
+	health := make(map[string]types.ValidatorHealth, len(addresses))
+	for _, address := range addresses {
+		if _, ok := health[address]; ok {
+			continue
+		}
+		resp, err := stakingClient.Validator(ctx, &staking.QueryValidatorRequest{ValidatorAddr: address})
+		if err != nil {
+			return nil, fmt.Errorf("querying validator %s: %w", address, err)
+		}
+		validator := resp.Validator
+		health[address] = validatorHealth(validator, validator.Jailed && tombstoned(ctx, slashingClient, validator))
+	}
+	return health, nil
+}
+
+// tombstoned reports whether the validator is tombstoned. Failing to find out is logged
+// and treated as merely jailed, which is handled alike.
+func tombstoned(ctx context.Context, client slashing.QueryClient, validator staking.Validator) bool {
+	if err := validator.UnpackInterfaces(interfaceRegistry); err != nil {
+		log.Debug().Err(err).Str("validator", validator.OperatorAddress).Msg("Unpacking consensus key")
+		return false
+	}
+	consAddress, err := validator.GetConsAddr()
+	if err != nil {
+		log.Debug().Err(err).Str("validator", validator.OperatorAddress).Msg("Getting consensus address")
+		return false
+	}
+	info, err := client.SigningInfo(ctx, &slashing.QuerySigningInfoRequest{ConsAddress: consAddress.String()})
+	if err != nil {
+		log.Debug().Err(err).Str("validator", validator.OperatorAddress).Msg("Querying signing info")
+		return false
+	}
+	return info.ValSigningInfo.Tombstoned
+}
+
+func validatorHealth(validator staking.Validator, tombstoned bool) types.ValidatorHealth {
+	switch {
+	case tombstoned:
+		return types.ValidatorHealth_TOMBSTONED
+	case validator.Jailed:
+		return types.ValidatorHealth_JAILED
+	case validator.Status == staking.Unbonding:
+		return types.ValidatorHealth_UNBONDING
+	case validator.Status != staking.Bonded:
+		return types.ValidatorHealth_UNBONDED
+	default:
+		return types.ValidatorHealth_HEALTHY
+	}
+}
+
+// Redistribute withholds the allocations of unhealthy validators and, depending on the
+// policy, hands their share to the healthy validators that were allocated to. If there
+// are none it is split equally between the healthy validators in fallback, and if there
+// are none of those either it stays undelegated. It returns the updated allocations along
+// with the unhealthy validators that had a share withheld and how much.
+func Redistribute(allocations []Allocation, health map[string]types.ValidatorHealth, fallback []string, policy types.UnhealthyPolicy) ([]Allocation, []*types.UnhealthyValidator) {
+	var (
+		unhealthy  []*types.UnhealthyValidator
+		withheld   int64
+		recipients []ValidatorShare
+		weights    []*big.Rat
+		indexes    = make(map[string]int, len(allocations))
+	)
+	allocations = append([]Allocation(nil), allocations...)
+	for idx, allocation := range allocations {
+		indexes[allocation.ValidatorAddress] = idx
+		if status := health[allocation.ValidatorAddress]; status != types.ValidatorHealth_HEALTHY {
+			// only validators that had a share withheld are reported
+			if allocation.Amount > 0 {
+				unhealthy = append(unhealthy, &types.UnhealthyValidator{
+					ValidatorAddress: allocation.ValidatorAddress,
+					Health:           status,
+					Withheld:         allocation.Amount,
+				})
+			}
+			withheld += allocation.Amount
+			allocations[idx].Amount = 0
+			continue
+		}
+		if allocation.Amount > 0 {
+			recipients = append(recipients, ValidatorShare{ValidatorAddress: allocation.ValidatorAddress})
+			if policy == types.UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY {
+				weights = append(weights, new(big.Rat).SetInt64(allocation.Amount))
+			} else {
+				weights = append(weights, big.NewRat(1, 1))
+			}
+		}
+	}
+	if withheld <= 0 || policy == types.UnhealthyPolicy_KEEP_UNDELEGATED {
+		return allocations, unhealthy
+	}
+	if len(recipients) == 0 {
+		for _, address := range fallback {
+			if status, ok := health[address]; !ok || status != types.ValidatorHealth_HEALTHY {
+				continue
+			}
+			recipients = append(recipients, ValidatorShare{ValidatorAddress: address})
+			weights = append(weights, big.NewRat(1, 1))
+		}
+	}
+	if len(recipients) == 0 {
+		return allocations, unhealthy
+	}
+	shares, err := split(withheld, recipients, weights)
+	if err != nil {
+		// the weights are positive
+		panic(err)
+	}
+	for _, share := range shares {
+		idx, ok := indexes[share.ValidatorAddress]
+		if !ok {
+			idx = len(allocations)
+			indexes[share.ValidatorAddress] = idx
+			allocations = append(allocations, Allocation{ValidatorAddress: share.ValidatorAddress})
+		}
+		allocations[idx].Amount += share.Amount
+	}
+	return allocations, unhealthy
+}
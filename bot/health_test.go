@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"testing"
+
+	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestValidatorHealth(t *testing.T) {
+	require.Equal(t, types.ValidatorHealth_HEALTHY, validatorHealth(staking.Validator{Status: staking.Bonded}, false))
+	require.Equal(t, types.ValidatorHealth_JAILED, validatorHealth(staking.Validator{Status: staking.Bonded, Jailed: true}, false))
+	require.Equal(t, types.ValidatorHealth_TOMBSTONED, validatorHealth(staking.Validator{Status: staking.Unbonding, Jailed: true}, true))
+	require.Equal(t, types.ValidatorHealth_UNBONDING, validatorHealth(staking.Validator{Status: staking.Unbonding}, false))
+	require.Equal(t, types.ValidatorHealth_UNBONDED, validatorHealth(staking.Validator{Status: staking.Unbonded}, false))
+}
+
+func TestRedistribute(t *testing.T) {
+	health := map[string]types.ValidatorHealth{
+		"a":      types.ValidatorHealth_HEALTHY,
+		"b":      types.ValidatorHealth_HEALTHY,
+		"jailed": types.ValidatorHealth_JAILED,
+		"gone":   types.ValidatorHealth_UNBONDED,
+	}
+	allocations := []Allocation{{"a", 100}, {"b", 300}, {"jailed", 400}}
+	withheld := []*types.UnhealthyValidator{{ValidatorAddress: "jailed", Health: types.ValidatorHealth_JAILED, Withheld: 400}}
+
+	result, unhealthy := Redistribute(allocations, health, nil, types.UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY)
+	require.Equal(t, []Allocation{{"a", 200}, {"b", 600}, {"jailed", 0}}, result)
+	require.Equal(t, withheld, unhealthy)
+	// the allocations passed in are left untouched
+	require.EqualValues(t, 400, allocations[2].Amount)
+
+	result, unhealthy = Redistribute(allocations, health, nil, types.UnhealthyPolicy_REDISTRIBUTE_EQUALLY)
+	require.Equal(t, []Allocation{{"a", 300}, {"b", 500}, {"jailed", 0}}, result)
+	require.Equal(t, withheld, unhealthy)
+
+	result, unhealthy = Redistribute(allocations, health, nil, types.UnhealthyPolicy_KEEP_UNDELEGATED)
+	require.Equal(t, []Allocation{{"a", 100}, {"b", 300}, {"jailed", 0}}, result)
+	require.Equal(t, withheld, unhealthy)
+
+	// a single unhealthy target falls back to the healthy validators claimed from
+	result, _ = Redistribute([]Allocation{{"gone", 1000}}, health, []string{"jailed", "a", "b"}, types.UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY)
+	require.Equal(t, []Allocation{{"gone", 0}, {"a", 500}, {"b", 500}}, result)
+
+	// without any healthy validator nothing is delegated
+	result, unhealthy = Redistribute([]Allocation{{"gone", 1000}}, health, []string{"jailed"}, types.UnhealthyPolicy_REDISTRIBUTE_EQUALLY)
+	require.Equal(t, []Allocation{{"gone", 0}}, result)
+	require.Len(t, unhealthy, 1)
+
+	// healthy allocations are returned as is
+	result, unhealthy = Redistribute(allocations[:2], health, nil, types.UnhealthyPolicy_REDISTRIBUTE_EQUALLY)
+	require.Equal(t, allocations[:2], result)
+	require.Empty(t, unhealthy)
+
+	// unhealthy validators without a share are not reported
+	result, unhealthy = Redistribute([]Allocation{{"a", 100}, {"jailed", 0}}, health, nil, types.UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY)
+	require.Equal(t, []Allocation{{"a", 100}, {"jailed", 0}}, result)
+	require.Empty(t, unhealthy)
+}
@@ -3,6 +3,7 @@ package bot
 import (
 	"context"
 	"fmt"
+	"time"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/authz"
@@ -13,22 +14,61 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/plural-labs/stakebot/client"
+	"github.com/plural-labs/stakebot/types"
 )
 
+// RestakeOptions are the settings a record is restaked with
+type RestakeOptions struct {
+	// Tolerance is the balance left undelegated to pay for future transactions
+	Tolerance int64
+	// MinRewards is the least amount of rewards worth restaking, smaller rewards are skipped
+	MinRewards      int64
+	Allocator       Allocator
+	UnhealthyPolicy types.UnhealthyPolicy
+	// Operator also withdraws the commission of the validator operated by the address
+	Operator bool
+	Fee      sdk.Coin
+}
+
+// RestakeOptions returns the options the record is restaked with on the chain
+func (bot AutoStakeBot) RestakeOptions(chain types.Chain, record *types.Record) (RestakeOptions, error) {
+	allocator, err := NewAllocator(chain, record)
+	if err != nil {
+		return RestakeOptions{}, err
+	}
+	return RestakeOptions{
+		Tolerance:       record.Tolerance,
+		MinRewards:      chain.RewardThreshold(record),
+		Allocator:       allocator,
+		UnhealthyPolicy: record.UnhealthyPolicy,
+		Operator:        record.Type == types.RecordType_VALIDATOR_OPERATOR,
+		Fee:             sdk.NewInt64Coin(chain.NativeDenom, chain.RestakeFee),
+	}, nil
+}
+
 // Restake queries an addresses' delegations. It executes a claim call on all delegations. It then calculates
-// a users liquid balance in the staking denom. It divides the balance proportionally to the delegated validators
-// and bundles together delegate msgs to effectively restake all available tokens above the specified tolerance.
+// a users liquid balance in the staking denom. The allocator divides the balance between validators, withholding
+// it from jailed and unbonded validators according to the unhealthy policy, and bundles together delegate msgs to
+// effectively restake all available tokens above the specified tolerance.
 // This is a blocking function.
 // NOTE: This only allows staking of the native token. I haven't seen a chain yet where you can stake other tokens
 // but correct me if I'm wrong.
-func (bot AutoStakeBot) Restake(ctx context.Context, address string, tolerance int64, fee sdk.Coin) (int64, error) {
-	chain, err := bot.chains.FindChainFromAddress(address)
+// Operators also withdraw the commission of their validator, which counts as rewards of that validator.
+// Rewards below the minimum are not worth the fee and are skipped.
+// Every attempt is appended to the address' history log. The event is returned along
+// with any error and holds the amounts claimed from and delegated to each validator.
+func (bot AutoStakeBot) Restake(ctx context.Context, chainID, address string, opts RestakeOptions) (event *types.Event, err error) {
+	event = &types.Event{ChainId: chainID, Address: address}
+	start := time.Now()
+	defer func() { bot.appendEvent(event, start, err) }()
+
+	chain, err := bot.chains.FindChain(chainID, address)
 	if err != nil {
-		return 0, err
+		return event, err
 	}
 	conn, err := grpc.Dial(chain.GRPC, grpc.WithInsecure())
 	if err != nil {
-		return 0, err
+		return event, err
 	}
 	defer conn.Close()
 
@@ -40,41 +80,163 @@ func (bot AutoStakeBot) Restake(ctx context.Context, address string, tolerance i
 			DelegatorAddress: address,
 		},
 	)
+	if err != nil {
+		return event, err
+	}
 
-	// Check if there are any rewards to claim
-	totalRewards := delegations.Total.AmountOf(chain.NativeDenom).RoundInt64()
+	// Check if there are any rewards to claim. Withdrawing truncates the rewards of each
+	// validator, so the total is the sum of the truncated amounts.
+	var totalRewards int64
+	for _, delegation := range delegations.Rewards {
+		totalRewards += delegation.Reward.AmountOf(chain.NativeDenom).TruncateInt64()
+	}
+	event.TotalRewards = totalRewards
 	log.Info().Interface("rewards", delegations).Str("address", address).Int64("totalRewards", totalRewards).Msg("Total rewards")
-	if totalRewards <= 0 {
-		return 0, nil
+
+	var operatorAddress string
+	if opts.Operator {
+		operatorAddress, err = chain.OperatorAddress(address)
+		if err != nil {
+			return event, err
+		}
+		commission, err := distributionClient.ValidatorCommission(ctx, &distribution.QueryValidatorCommissionRequest{ValidatorAddress: operatorAddress})
+		if err != nil {
+			return event, err
+		}
+		event.Commission = commission.Commission.Commission.AmountOf(chain.NativeDenom).TruncateInt64()
+		log.Info().Str("address", address).Str("validator", operatorAddress).Int64("commission", event.Commission).Msg("Validator commission")
 	}
 
-	msgs := make([]sdk.Msg, len(delegations.Rewards)*2)
-	for idx, delegation := range delegations.Rewards {
+	claimable := totalRewards + event.Commission
+	if claimable <= 0 {
+		return event, nil
+	}
+	if claimable < opts.MinRewards {
+		event.SkipReason = types.SkipReason_BELOW_THRESHOLD
+		log.Info().Str("address", address).Int64("totalRewards", claimable).Int64("minRewards", opts.MinRewards).Msg("Skipping restake below threshold")
+		return event, nil
+	}
+
+	msgs := make([]sdk.Msg, 0, len(delegations.Rewards)*2+1)
+	for _, delegation := range delegations.Rewards {
 		claimMsg := &distribution.MsgWithdrawDelegatorReward{
 			DelegatorAddress: address,
 			ValidatorAddress: delegation.ValidatorAddress,
 		}
-		msgs[idx] = claimMsg
+		msgs = append(msgs, claimMsg)
+	}
+	if event.Commission > 0 {
+		msgs = append(msgs, &distribution.MsgWithdrawValidatorCommission{ValidatorAddress: operatorAddress})
 	}
 
 	resp, err := bankClient.Balance(ctx, &bank.QueryBalanceRequest{Address: address, Denom: chain.NativeDenom})
 	if err != nil {
-		return 0, err
+		return event, err
+	}
+	delegated, err := staking.NewQueryClient(conn).DelegatorDelegations(ctx, &staking.QueryDelegatorDelegationsRequest{DelegatorAddr: address})
+	if err != nil {
+		return event, err
+	}
+	delegatedTo := make(map[string]int64, len(delegated.DelegationResponses))
+	for _, delegation := range delegated.DelegationResponses {
+		if delegation.Balance.Denom == chain.NativeDenom {
+			delegatedTo[delegation.Delegation.ValidatorAddress] = delegation.Balance.Amount.Int64()
+		}
 	}
 
 	// Caclulate how much native token after claiming can be restaked
-	stakableBalance := resp.Balance.Amount.Int64() + totalRewards - tolerance
+	stakableBalance := resp.Balance.Amount.Int64() + claimable - opts.Tolerance
+	shares := make([]ValidatorShare, len(delegations.Rewards))
+	event.Validators = make([]*types.ValidatorRestake, len(delegations.Rewards))
+	restakes := make(map[string]*types.ValidatorRestake, len(delegations.Rewards))
 	for idx, delegation := range delegations.Rewards {
-		amount := (stakableBalance * delegation.Reward.AmountOf(chain.NativeDenom).RoundInt64()) / totalRewards
-		log.Info().Int64("stakableBalance", stakableBalance).Int64("amount", amount).Msg("restake")
+		claimed := delegation.Reward.AmountOf(chain.NativeDenom).TruncateInt64()
+		shares[idx] = ValidatorShare{
+			ValidatorAddress: delegation.ValidatorAddress,
+			Rewards:          claimed,
+			Delegated:        delegatedTo[delegation.ValidatorAddress],
+		}
+		event.Validators[idx] = &types.ValidatorRestake{
+			ValidatorAddress: delegation.ValidatorAddress,
+			Claimed:          claimed,
+		}
+		restakes[delegation.ValidatorAddress] = event.Validators[idx]
+	}
+	if event.Commission > 0 {
+		// the commission is allocated like rewards of the operated validator, which is
+		// where it goes with the default allocation. It is recorded separately from the
+		// rewards claimed from the validator.
+		if _, ok := restakes[operatorAddress]; ok {
+			for idx := range shares {
+				if shares[idx].ValidatorAddress == operatorAddress {
+					shares[idx].Rewards += event.Commission
+				}
+			}
+		} else {
+			shares = append(shares, ValidatorShare{
+				ValidatorAddress: operatorAddress,
+				Rewards:          event.Commission,
+				Delegated:        delegatedTo[operatorAddress],
+			})
+			restakes[operatorAddress] = &types.ValidatorRestake{ValidatorAddress: operatorAddress}
+			event.Validators = append(event.Validators, restakes[operatorAddress])
+		}
+	}
+
+	allocations, err := opts.Allocator.Allocate(stakableBalance, shares)
+	if err != nil {
+		return event, fmt.Errorf("error allocating rewards: %w", err)
+	}
+
+	// jailed and unbonded validators earn nothing, so their share is withheld
+	claimedFrom := make([]string, len(shares))
+	for idx, share := range shares {
+		claimedFrom[idx] = share.ValidatorAddress
+	}
+	validators := append([]string(nil), claimedFrom...)
+	for _, allocation := range allocations {
+		if allocation.Amount > 0 {
+			validators = append(validators, allocation.ValidatorAddress)
+		}
+	}
+	health, err := queryValidatorHealth(ctx, conn, validators)
+	if err != nil {
+		return event, err
+	}
+	allocations, event.UnhealthyValidators = Redistribute(allocations, health, claimedFrom, opts.UnhealthyPolicy)
+	if len(event.UnhealthyValidators) > 0 {
+		event.UnhealthyPolicy = opts.UnhealthyPolicy
+		for _, validator := range event.UnhealthyValidators {
+			log.Info().
+				Str("address", address).
+				Str("validator", validator.ValidatorAddress).
+				Str("health", validator.Health.String()).
+				Int64("withheld", validator.Withheld).
+				Str("policy", opts.UnhealthyPolicy.String()).
+				Msg("Withholding delegation from unhealthy validator")
+		}
+	}
+	for _, allocation := range allocations {
+		if allocation.Amount <= 0 {
+			continue
+		}
+		restake, ok := restakes[allocation.ValidatorAddress]
+		if !ok {
+			// the allocation targets a validator no rewards were claimed from
+			restake = &types.ValidatorRestake{ValidatorAddress: allocation.ValidatorAddress}
+			restakes[allocation.ValidatorAddress] = restake
+			event.Validators = append(event.Validators, restake)
+		}
+		restake.Delegated += allocation.Amount
+		log.Info().Int64("stakableBalance", stakableBalance).Int64("amount", allocation.Amount).Msg("restake")
 
 		delegateMsg := &staking.MsgDelegate{
 			DelegatorAddress: address,
-			ValidatorAddress: delegation.ValidatorAddress,
-			Amount:           sdk.NewInt64Coin(chain.NativeDenom, amount),
+			ValidatorAddress: allocation.ValidatorAddress,
+			Amount:           sdk.NewInt64Coin(chain.NativeDenom, allocation.Amount),
 		}
 		log.Info().Str("amount", delegateMsg.Amount.String()).Str("delegator", delegateMsg.DelegatorAddress).Str("validator", delegateMsg.ValidatorAddress).Msg("Delegate")
-		msgs[idx+len(delegations.Rewards)] = delegateMsg
+		msgs = append(msgs, delegateMsg)
 	}
 
 	// wrap all the messages in an auth exec message
@@ -89,17 +251,61 @@ func (bot AutoStakeBot) Restake(ctx context.Context, address string, tolerance i
 	authzMsg := authz.NewMsgExec(accAddress, msgs)
 	log.Info().Str("botAddress", botBech32Addr).Str("userAddress", address).Msg("Prepared messages")
 
+	if bot.config.DryRun {
+		result, err := bot.client.Simulate(ctx, chain.Id, []sdk.Msg{&authzMsg}, client.WithGranter(address), client.WithPubKey(), client.WithFee(opts.Fee))
+		if err != nil {
+			return event, fmt.Errorf("error simulating messages: %w", err)
+		}
+		event.GasUsed = result.GasUsed
+		event.DryRunTx = string(result.JSON)
+		log.Info().
+			Str("address", address).
+			Uint64("gasUsed", result.GasUsed).
+			Int("msgs", len(authzMsg.Msgs)).
+			RawJSON("tx", result.JSON).
+			Msg("Simulated restake transaction")
+		return event, nil
+	}
+
 	// TODO: Might be helpful to catch the results and log them to INFO for debugging
-	txResp, err := bot.client.Send(ctx, []sdk.Msg{&authzMsg}, client.WithGranter(address), client.WithPubKey(), client.WithFee(fee))
+	txResp, err := bot.client.Send(ctx, chain.Id, []sdk.Msg{&authzMsg}, client.WithGranter(address), client.WithPubKey(), client.WithFee(opts.Fee))
 	if err != nil {
-		return 0, fmt.Errorf("error sending messages: %w", err)
+		return event, fmt.Errorf("error sending messages: %w", err)
 	}
+	event.TxHash = txResp.TxHash
+	event.Height = txResp.Height
+	event.Fee = opts.Fee.Amount.Int64()
 
 	if txResp.Code != 0 {
-		return 0, fmt.Errorf("failed to submit restake transaction: %v", txResp.RawLog)
+		return event, fmt.Errorf("failed to submit restake transaction: %v", txResp.RawLog)
 	}
 
 	log.Info().Str("data", txResp.Data).Str("logs", txResp.RawLog).Msg("Succesfully sumbitted transaction")
 
-	return totalRewards, nil
+	return event, nil
+}
+
+// appendEvent completes a restake event with its timing and outcome and saves it to the
+// history log. Failing to save the event does not fail the restake.
+func (bot AutoStakeBot) appendEvent(event *types.Event, start time.Time, err error) {
+	event.UnixNanoTime = start.UnixNano()
+	event.DurationMs = time.Since(start).Milliseconds()
+	switch {
+	case err != nil:
+		event.Outcome = types.Outcome_FAILURE
+		event.Error = err.Error()
+		event.ErrorCode = ClassifyError(err)
+	case event.SkipReason != types.SkipReason_NOT_SKIPPED:
+		event.Outcome = types.Outcome_SKIPPED
+	case event.DryRunTx != "":
+		event.Outcome = types.Outcome_SIMULATED
+	case event.TxHash == "":
+		event.Outcome = types.Outcome_NO_REWARDS
+	default:
+		event.Outcome = types.Outcome_SUCCESS
+	}
+
+	if err := bot.Store.AppendEvent(event); err != nil {
+		log.Error().Err(err).Str("address", event.Address).Msg("Saving history event")
+	}
 }
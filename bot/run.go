@@ -0,0 +1,257 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+// limits bounds the amount of concurrent restakes, both overall and per chain. They are
+// shared by all runs of a bot.
+type limits struct {
+	workers chan struct{}
+	// chains only holds the chains with a concurrency limit
+	chains map[string]chan struct{}
+}
+
+func newLimits(workers int, chains []types.Chain) *limits {
+	l := &limits{
+		workers: make(chan struct{}, workers),
+		chains:  make(map[string]chan struct{}),
+	}
+	for _, chain := range chains {
+		if chain.Concurrency > 0 {
+			l.chains[chain.Id] = make(chan struct{}, chain.Concurrency)
+		}
+	}
+	return l
+}
+
+// acquire blocks until a slot is free on the chain and overall and returns a function
+// releasing both
+func (l *limits) acquire(chainID string) func() {
+	chain, ok := l.chains[chainID]
+	if ok {
+		chain <- struct{}{}
+	}
+	l.workers <- struct{}{}
+	return func() {
+		<-l.workers
+		if ok {
+			<-chain
+		}
+	}
+}
+
+// concurrency returns the amount of workers worth starting for a chain
+func (l *limits) concurrency(chainID string) int {
+	if chain, ok := l.chains[chainID]; ok && cap(chain) < cap(l.workers) {
+		return cap(chain)
+	}
+	return cap(l.workers)
+}
+
+// RunSummary aggregates the outcomes of all restakes of a run
+type RunSummary struct {
+	Start    time.Time
+	Duration time.Duration
+	Chains   map[string]*ChainSummary
+}
+
+// ChainSummary counts the outcomes of the restakes on a single chain. Rewards are in the
+// native denom of the chain.
+type ChainSummary struct {
+	Succeeded int
+	NoRewards int
+	Skipped   int
+	Simulated int
+	Failed    int
+	Rewards   int64
+}
+
+// Total sums the outcomes of all chains, apart from the rewards which are in different
+// denominations
+func (s RunSummary) Total() ChainSummary {
+	var total ChainSummary
+	for _, chain := range s.Chains {
+		total.Succeeded += chain.Succeeded
+		total.NoRewards += chain.NoRewards
+		total.Skipped += chain.Skipped
+		total.Simulated += chain.Simulated
+		total.Failed += chain.Failed
+	}
+	return total
+}
+
+// Run restakes the records using a pool of workers per chain, bounded by the configured
+// limits, and saves the results. It blocks until all records have been restaked.
+func (bot AutoStakeBot) Run(records []*types.Record) RunSummary {
+	summary := RunSummary{Start: time.Now(), Chains: make(map[string]*ChainSummary)}
+	byChain := make(map[string][]*types.Record)
+	for _, record := range records {
+		byChain[record.ChainId] = append(byChain[record.ChainId], record)
+		if _, ok := summary.Chains[record.ChainId]; !ok {
+			summary.Chains[record.ChainId] = &ChainSummary{}
+		}
+	}
+	log.Info().Int("records", len(records)).Int("chains", len(byChain)).Msg("Starting restake run")
+
+	var (
+		wg  sync.WaitGroup
+		mtx sync.Mutex
+	)
+	for chainID, chainRecords := range byChain {
+		queue := make(chan *types.Record, len(chainRecords))
+		for _, record := range chainRecords {
+			queue <- record
+		}
+		close(queue)
+
+		workers := bot.limits.concurrency(chainID)
+		if workers > len(chainRecords) {
+			workers = len(chainRecords)
+		}
+		chainSummary := summary.Chains[chainID]
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for record := range queue {
+					if bot.ctx.Err() != nil {
+						// the bot is stopping, records that haven't started are left
+						// to the next run
+						continue
+					}
+					release := bot.limits.acquire(record.ChainId)
+					event, err := bot.restakeRecord(record)
+					release()
+
+					mtx.Lock()
+					switch {
+					case err != nil:
+						chainSummary.Failed++
+					case event.Outcome == types.Outcome_SUCCESS:
+						chainSummary.Succeeded++
+						chainSummary.Rewards += event.TotalRewards
+					case event.Outcome == types.Outcome_SKIPPED:
+						chainSummary.Skipped++
+					case event.Outcome == types.Outcome_SIMULATED:
+						chainSummary.Simulated++
+					default:
+						chainSummary.NoRewards++
+					}
+					mtx.Unlock()
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	summary.Duration = time.Since(summary.Start)
+
+	for chainID, chain := range summary.Chains {
+		log.Info().
+			Str("chain", chainID).
+			Int("succeeded", chain.Succeeded).
+			Int("noRewards", chain.NoRewards).
+			Int("skipped", chain.Skipped).
+			Int("simulated", chain.Simulated).
+			Int("failed", chain.Failed).
+			Int64("rewards", chain.Rewards).
+			Msg("Restaked chain")
+	}
+	total := summary.Total()
+	log.Info().
+		Int("records", len(records)).
+		Int("succeeded", total.Succeeded).
+		Int("noRewards", total.NoRewards).
+		Int("skipped", total.Skipped).
+		Int("simulated", total.Simulated).
+		Int("failed", total.Failed).
+		Dur("duration", summary.Duration).
+		Msg("Completed restake run")
+	return summary
+}
+
+// RunOnce restakes all records matching the filter once, skipping paused and suspended
+// records like the scheduler does. It is meant for running the bot from an external
+// scheduler instead of StartJobs. If the bot is cancelled the restakes in progress are
+// cancelled and recorded, the remaining records are skipped and an error is returned
+// along with the summary of the restakes that were attempted.
+func (bot AutoStakeBot) RunOnce(filter store.Filter) (RunSummary, error) {
+	filter.Cursor, filter.Limit = "", 0
+	records, _, err := bot.Store.List(filter)
+	if err != nil {
+		return RunSummary{}, err
+	}
+	paused, err := bot.pausedChains()
+	if err != nil {
+		return RunSummary{}, err
+	}
+	selected := records[:0]
+	for _, record := range records {
+		if runnable(record, paused) {
+			selected = append(selected, record)
+		}
+	}
+	summary := bot.Run(selected)
+	if err := bot.ctx.Err(); err != nil {
+		return summary, fmt.Errorf("run interrupted: %w", err)
+	}
+	return summary, nil
+}
+
+// restakeRecord restakes a single record and saves the result
+func (bot AutoStakeBot) restakeRecord(record *types.Record) (*types.Event, error) {
+	chain, err := bot.chains.FindChainById(record.ChainId)
+	if err != nil {
+		log.Error().Err(err).Str("address", record.Address).Msg("Finding chain")
+		return nil, err
+	}
+
+	opts, err := bot.RestakeOptions(chain, record)
+	if err != nil {
+		log.Error().Err(err).Str("address", record.Address).Msg("Finding allocation strategy")
+		return nil, err
+	}
+	event, err := withRetries(bot.ctx, bot.config.Retries, bot.config.RetryBackoff.Duration, func() (*types.Event, error) {
+		ctx, cancel := bot.RestakeContext()
+		defer cancel()
+		event, err := bot.Restake(ctx, chain.Id, record.Address, opts)
+		if err != nil && Retryable(ClassifyError(err)) {
+			log.Warn().Err(err).Str("address", record.Address).Msg("Restake failed with a transient error")
+		}
+		return event, err
+	})
+	if err != nil {
+		log.Error().Err(err).Str("address", record.Address).Msg("Restaking")
+	}
+	if err := bot.SaveResult(event, err); err != nil {
+		log.Error().Err(err).Str("address", record.Address).Msg("Saving record")
+	}
+	return event, err
+}
+
+// withRetries calls restake until it succeeds, fails with an error that is not
+// retryable or has been retried the given amount of times. The backoff doubles after
+// every retry. It returns early with the last result once ctx is done.
+func withRetries(ctx context.Context, retries int, backoff time.Duration, restake func() (*types.Event, error)) (*types.Event, error) {
+	event, err := restake()
+	for i := 0; i < retries && err != nil && Retryable(ClassifyError(err)); i++ {
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return event, err
+		case <-timer.C:
+		}
+		backoff *= 2
+		event, err = restake()
+	}
+	return event, err
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestLimits(t *testing.T) {
+	l := newLimits(4, []types.Chain{{Id: "limited", Concurrency: 2}, {Id: "wide", Concurrency: 10}})
+	require.Equal(t, 2, l.concurrency("limited"))
+	require.Equal(t, 4, l.concurrency("wide"))
+	require.Equal(t, 4, l.concurrency("unlimited"))
+
+	var (
+		wg                 sync.WaitGroup
+		total, limited     int32
+		maxTotal, maxLimit int32
+	)
+	record := func(counter *int32, max *int32) {
+		if n := atomic.AddInt32(counter, 1); n > atomic.LoadInt32(max) {
+			atomic.StoreInt32(max, n)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		chainID := "limited"
+		if i%2 == 0 {
+			chainID = "unlimited"
+		}
+		wg.Add(1)
+		go func(chainID string) {
+			defer wg.Done()
+			release := l.acquire(chainID)
+			defer release()
+			record(&total, &maxTotal)
+			if chainID == "limited" {
+				record(&limited, &maxLimit)
+				defer atomic.AddInt32(&limited, -1)
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&total, -1)
+		}(chainID)
+	}
+	wg.Wait()
+	require.LessOrEqual(t, maxTotal, int32(4))
+	require.LessOrEqual(t, maxLimit, int32(2))
+}
+
+func TestRunSummaryTotal(t *testing.T) {
+	summary := RunSummary{Chains: map[string]*ChainSummary{
+		"a": {Succeeded: 2, Failed: 1, Rewards: 100},
+		"b": {Succeeded: 1, NoRewards: 3, Skipped: 4, Simulated: 2, Rewards: 5},
+	}}
+	require.Equal(t, ChainSummary{Succeeded: 3, NoRewards: 3, Skipped: 4, Simulated: 2, Failed: 1}, summary.Total())
+}
+
+func TestWithRetries(t *testing.T) {
+	transient := errors.New("dial tcp: connection refused")
+	permanent := errors.New("authorization not found")
+
+	var calls int
+	failing := func(errs ...error) func() (*types.Event, error) {
+		calls = 0
+		return func() (*types.Event, error) {
+			calls++
+			if calls <= len(errs) {
+				return &types.Event{}, errs[calls-1]
+			}
+			return &types.Event{Outcome: types.Outcome_SUCCESS}, nil
+		}
+	}
+
+	// transient errors are retried until the restake succeeds
+	event, err := withRetries(context.Background(), 3, time.Millisecond, failing(transient, transient))
+	require.NoError(t, err)
+	require.Equal(t, types.Outcome_SUCCESS, event.Outcome)
+	require.Equal(t, 3, calls)
+
+	// but only as many times as configured
+	_, err = withRetries(context.Background(), 2, time.Millisecond, failing(transient, transient, transient))
+	require.Equal(t, transient, err)
+	require.Equal(t, 3, calls)
+
+	// other errors are not retried
+	_, err = withRetries(context.Background(), 3, time.Millisecond, failing(permanent))
+	require.Equal(t, permanent, err)
+	require.Equal(t, 1, calls)
+
+	// nor once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = withRetries(ctx, 3, time.Hour, failing(transient))
+	require.Equal(t, transient, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestRunOnceSkipsPausedAndSuspended(t *testing.T) {
+	db := store.NewMemory()
+	bot := AutoStakeBot{Store: db, limits: newLimits(2, nil), ctx: context.Background()}
+	// the chains are unknown to the bot so every restake fails before dialing a node
+	for _, record := range []*types.Record{
+		{ChainId: "test-1", Address: "active", Frequency: types.Frequency_DAILY},
+		{ChainId: "test-1", Address: "weekly", Frequency: types.Frequency_WEEKLY},
+		{ChainId: "test-1", Address: "paused", Frequency: types.Frequency_DAILY, Paused: true},
+		{ChainId: "test-1", Address: "suspended", Frequency: types.Frequency_DAILY, Suspended: true},
+		{ChainId: "test-2", Address: "chain-paused", Frequency: types.Frequency_DAILY},
+	} {
+		require.NoError(t, db.SetRecord(record))
+	}
+	require.NoError(t, db.SetChainPaused("test-2", true))
+
+	summary, err := bot.RunOnce(store.Filter{Frequency: types.Frequency_DAILY})
+	require.NoError(t, err)
+	require.Equal(t, ChainSummary{Failed: 1}, summary.Total())
+
+	summary, err = bot.RunOnce(store.Filter{ChainID: "test-1"})
+	require.NoError(t, err)
+	require.Equal(t, ChainSummary{Failed: 2}, summary.Total())
+}
+
+func TestRunOnceCancelled(t *testing.T) {
+	db := store.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	bot := AutoStakeBot{Store: db, limits: newLimits(2, nil), ctx: ctx, cancel: cancel}
+	require.NoError(t, db.SetRecord(&types.Record{ChainId: "test-1", Address: "active", Frequency: types.Frequency_DAILY}))
+
+	// records that haven't started when the bot is cancelled are not attempted
+	bot.Cancel()
+	summary, err := bot.RunOnce(store.Filter{})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, ChainSummary{}, summary.Total())
+	record, err := db.GetRecord("test-1", "active")
+	require.NoError(t, err)
+	require.Nil(t, record.LastRun)
+}
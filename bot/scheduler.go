@@ -0,0 +1,260 @@
+package bot
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+// scheduledRecord is a record waiting for its next run
+type scheduledRecord struct {
+	chainID  string
+	address  string
+	spec     string
+	schedule cron.Schedule
+	next     time.Time
+	// auto is set for records with the AUTO frequency whose interval is recomputed
+	auto bool
+	// catchUp is set while a run missed before the record was scheduled is pending
+	catchUp bool
+	// index is the position in the queue or -1 while the record is being restaked
+	index int
+}
+
+// scheduleQueue is a min heap of records ordered by their next run
+type scheduleQueue []*scheduledRecord
+
+func (q scheduleQueue) Len() int           { return len(q) }
+func (q scheduleQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+func (q scheduleQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *scheduleQueue) Push(x interface{}) {
+	entry := x.(*scheduledRecord)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *scheduleQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// scheduler keeps track of the next run of every record. Records that are due are
+// taken out of the queue until they are rescheduled after their restake.
+//
+// Records that missed a run since their last attempt, e.g. while the bot was down, are
+// due as soon as they are scheduled. At most catchUpLimit of them are taken at once so
+// that a long downtime doesn't restake every record at the same time. A limit of zero
+// disables catching up and overdue records wait for their next run.
+type scheduler struct {
+	mtx          sync.Mutex
+	queue        scheduleQueue
+	records      map[string]*scheduledRecord
+	catchUpLimit int
+	// wake is signalled when a record is scheduled earlier than the previous first run
+	wake chan struct{}
+}
+
+func newScheduler(catchUpLimit int) *scheduler {
+	return &scheduler{
+		queue:        make(scheduleQueue, 0),
+		records:      make(map[string]*scheduledRecord),
+		catchUpLimit: catchUpLimit,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// set schedules a new record or updates the schedule of a known one. Records keep
+// their next run unless their schedule changed. New records that are overdue are due
+// immediately. A recomputed AUTO interval never postpones the next run, as a record whose
+// interval keeps changing would otherwise never become due.
+func (s *scheduler) set(record *types.Record, schedule cron.Schedule, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	spec := record.ScheduleSpec()
+	key := scheduleKey(record.ChainId, record.Address)
+	entry, ok := s.records[key]
+	if !ok {
+		entry = &scheduledRecord{chainID: record.ChainId, address: record.Address, index: -1}
+		s.records[key] = entry
+	} else if entry.spec == spec {
+		return
+	}
+	auto := record.Frequency == types.Frequency_AUTO && record.Schedule == ""
+	next := schedule.Next(now.UTC())
+	if ok && entry.auto && auto && entry.next.Before(next) {
+		next = entry.next
+	}
+	entry.spec = spec
+	entry.schedule = schedule
+	entry.auto = auto
+	entry.next = next
+	if !ok && s.catchUpLimit > 0 && overdue(record, schedule, now) {
+		entry.next = now
+		entry.catchUp = true
+	}
+	switch {
+	case entry.index >= 0:
+		heap.Fix(&s.queue, entry.index)
+	case !ok:
+		heap.Push(&s.queue, entry)
+	default:
+		// the record is being restaked and is pushed back once done
+		return
+	}
+	if s.queue[0] == entry {
+		s.signal()
+	}
+}
+
+// scheduled reports whether the record is scheduled with its current schedule
+func (s *scheduler) scheduled(record *types.Record) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	entry, ok := s.records[scheduleKey(record.ChainId, record.Address)]
+	return ok && entry.spec == record.ScheduleSpec()
+}
+
+// remove stops scheduling a record
+func (s *scheduler) remove(chainID, address string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := scheduleKey(chainID, address)
+	entry, ok := s.records[key]
+	if !ok {
+		return
+	}
+	delete(s.records, key)
+	if entry.index >= 0 {
+		heap.Remove(&s.queue, entry.index)
+	}
+}
+
+// due takes all records whose next run is at or before now out of the queue, apart from
+// catch-ups beyond the limit which stay due for the following call
+func (s *scheduler) due(now time.Time) []*scheduledRecord {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var (
+		entries  = make([]*scheduledRecord, 0)
+		deferred []*scheduledRecord
+		catchUps int
+	)
+	for len(s.queue) > 0 && !s.queue[0].next.After(now) {
+		entry := heap.Pop(&s.queue).(*scheduledRecord)
+		if entry.catchUp {
+			if catchUps == s.catchUpLimit {
+				deferred = append(deferred, entry)
+				continue
+			}
+			catchUps++
+		}
+		entries = append(entries, entry)
+	}
+	for _, entry := range deferred {
+		heap.Push(&s.queue, entry)
+	}
+	return entries
+}
+
+// reschedule puts a record taken by due back in the queue at its next run after now.
+// Records removed in the meantime are dropped.
+func (s *scheduler) reschedule(entry *scheduledRecord, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.records[scheduleKey(entry.chainID, entry.address)] != entry || entry.index >= 0 {
+		return
+	}
+	entry.next = entry.schedule.Next(now.UTC())
+	entry.catchUp = false
+	heap.Push(&s.queue, entry)
+}
+
+// nextRun returns the time the first record is due
+func (s *scheduler) nextRun() (time.Time, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.queue) == 0 {
+		return time.Time{}, false
+	}
+	return s.queue[0].next, true
+}
+
+// nextRunOf returns the next run of a record unless it is not scheduled or being
+// restaked
+func (s *scheduler) nextRunOf(chainID, address string) (time.Time, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	entry, ok := s.records[scheduleKey(chainID, address)]
+	if !ok || entry.index < 0 {
+		return time.Time{}, false
+	}
+	return entry.next, true
+}
+
+// entries returns a copy of all scheduled records by key
+func (s *scheduler) entries() map[string]*scheduledRecord {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	entries := make(map[string]*scheduledRecord, len(s.records))
+	for key, entry := range s.records {
+		entries[key] = entry
+	}
+	return entries
+}
+
+// catchUps returns the amount of records with a missed run pending
+func (s *scheduler) catchUps() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	var n int
+	for _, entry := range s.records {
+		if entry.catchUp {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *scheduler) len() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.records)
+}
+
+func (s *scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// overdue reports whether the record missed a run since it was last attempted. Records
+// that have never been attempted are not overdue.
+func overdue(record *types.Record, schedule cron.Schedule, now time.Time) bool {
+	last := record.LastUpdatedUnixTime
+	if entry := record.LastError(); entry != nil && entry.UnixTime > last {
+		last = entry.UnixTime
+	}
+	if last == 0 {
+		return false
+	}
+	return !schedule.Next(time.Unix(last, 0).UTC()).After(now)
+}
+
+func scheduleKey(chainID, address string) string {
+	return chainID + "\x00" + address
+}
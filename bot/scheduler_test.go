@@ -0,0 +1,158 @@
+package bot
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestScheduler(t *testing.T) {
+	s := newScheduler(0)
+	now := time.Date(2022, 1, 1, 12, 30, 0, 0, time.UTC)
+	set := func(address, spec string) *types.Record {
+		record := &types.Record{ChainId: "cosmoshub-4", Address: address, Schedule: spec}
+		schedule, err := types.ParseSchedule(spec)
+		require.NoError(t, err)
+		s.set(record, schedule, now)
+		return record
+	}
+
+	set("hourly", "@hourly")
+	set("daily", "@daily")
+	set("custom", "0 14 */3 * *")
+	require.Equal(t, 3, s.len())
+	next, ok := s.nextRun()
+	require.True(t, ok)
+	require.Equal(t, time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC), next)
+
+	// setting the same schedule again keeps the next run
+	set("hourly", "@hourly")
+	next, _ = s.nextRun()
+	require.Equal(t, time.Date(2022, 1, 1, 13, 0, 0, 0, time.UTC), next)
+
+	require.Empty(t, s.due(now))
+	due := s.due(time.Date(2022, 1, 1, 14, 0, 0, 0, time.UTC))
+	require.Len(t, due, 2)
+	require.Equal(t, "hourly", due[0].address)
+	require.Equal(t, "custom", due[1].address)
+	next, _ = s.nextRun()
+	require.Equal(t, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), next)
+
+	// records being restaked have no next run until they are rescheduled
+	_, ok = s.nextRunOf("cosmoshub-4", "hourly")
+	require.False(t, ok)
+	next, ok = s.nextRunOf("cosmoshub-4", "daily")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC), next)
+	_, ok = s.nextRunOf("cosmoshub-4", "unknown")
+	require.False(t, ok)
+
+	// a schedule changed while restaking applies once the record is rescheduled and
+	// removed records are not rescheduled
+	set("hourly", "@every 30m")
+	s.remove("cosmoshub-4", "custom")
+	done := time.Date(2022, 1, 1, 14, 5, 0, 0, time.UTC)
+	for _, entry := range due {
+		s.reschedule(entry, done)
+	}
+	require.Equal(t, 2, s.len())
+	next, _ = s.nextRun()
+	require.Equal(t, time.Date(2022, 1, 1, 14, 35, 0, 0, time.UTC), next)
+
+	// scheduling a record before the first run wakes up the loop
+	select {
+	case <-s.wake:
+	default:
+	}
+	set("soon", "1m")
+	select {
+	case <-s.wake:
+	default:
+		t.Fatal("expected the scheduler to be woken up")
+	}
+}
+
+func TestSchedulerAutoInterval(t *testing.T) {
+	s := newScheduler(0)
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	record := &types.Record{ChainId: "cosmoshub-4", Address: "auto", Frequency: types.Frequency_AUTO}
+	set := func(interval time.Duration, now time.Time) {
+		record.SetAutoInterval(interval, now)
+		schedule, err := types.ParseSchedule(record.ScheduleSpec())
+		require.NoError(t, err)
+		s.set(record, schedule, now)
+	}
+
+	set(48*time.Hour, now)
+	next, ok := s.nextRunOf("cosmoshub-4", "auto")
+	require.True(t, ok)
+	require.Equal(t, now.Add(48*time.Hour), next)
+
+	// a longer interval computed before the record is due keeps the next run
+	set(49*time.Hour, now.Add(24*time.Hour))
+	next, _ = s.nextRunOf("cosmoshub-4", "auto")
+	require.Equal(t, now.Add(48*time.Hour), next)
+	require.Len(t, s.due(now.Add(48*time.Hour)), 1)
+
+	// a shorter interval brings the next run forward
+	record = &types.Record{ChainId: "cosmoshub-4", Address: "shorter", Frequency: types.Frequency_AUTO}
+	set(48*time.Hour, now)
+	set(12*time.Hour, now.Add(time.Hour))
+	next, _ = s.nextRunOf("cosmoshub-4", "shorter")
+	require.Equal(t, now.Add(13*time.Hour), next)
+
+	// other schedule changes still apply from now
+	record.Frequency = types.Frequency_UNKNOWN
+	record.Schedule = "@every 72h"
+	schedule, err := types.ParseSchedule(record.Schedule)
+	require.NoError(t, err)
+	s.set(record, schedule, now.Add(2*time.Hour))
+	next, _ = s.nextRunOf("cosmoshub-4", "shorter")
+	require.Equal(t, now.Add(74*time.Hour), next)
+}
+
+func TestSchedulerCatchUp(t *testing.T) {
+	s := newScheduler(2)
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+	daily, err := types.ParseSchedule("@daily")
+	require.NoError(t, err)
+	set := func(address string, lastUpdated time.Time, lastError time.Time) {
+		record := &types.Record{ChainId: "cosmoshub-4", Address: address, Frequency: types.Frequency_DAILY}
+		if !lastUpdated.IsZero() {
+			record.LastUpdatedUnixTime = lastUpdated.Unix()
+		}
+		if !lastError.IsZero() {
+			record.AddError(&types.ErrorEntry{UnixTime: lastError.Unix()})
+		}
+		s.set(record, daily, now)
+	}
+
+	set("new", time.Time{}, time.Time{})
+	set("current", now.Add(-time.Hour), time.Time{})
+	set("retried", now.Add(-72*time.Hour), now.Add(-time.Hour))
+	for _, address := range []string{"missed1", "missed2", "missed3"} {
+		set(address, now.Add(-48*time.Hour), time.Time{})
+	}
+	require.Equal(t, 3, s.catchUps())
+
+	// overdue records are due at once but only two of them are taken at a time
+	require.Len(t, s.due(now), 2)
+	due := s.due(now)
+	require.Len(t, due, 1)
+	require.Empty(t, s.due(now))
+
+	// once restaked they return to their schedule
+	s.reschedule(due[0], now)
+	require.Equal(t, 2, s.catchUps())
+	next, _ := s.nextRun()
+	require.Equal(t, time.Date(2022, 1, 11, 0, 0, 0, 0, time.UTC), next)
+
+	// catching up can be disabled
+	s = newScheduler(0)
+	set("missed", now.Add(-48*time.Hour), time.Time{})
+	require.Zero(t, s.catchUps())
+	require.Empty(t, s.due(now))
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"sync"
+
 	"github.com/cosmos/cosmos-sdk/crypto/keyring"
 
 	"github.com/plural-labs/stakebot/types"
@@ -9,8 +11,11 @@ import (
 type Client struct {
 	signer keyring.Keyring
 	chains types.ChainRegistry
+
+	mtx        sync.Mutex
+	sequencers map[string]*sequencer
 }
 
 func New(signer keyring.Keyring, chains types.ChainRegistry) *Client {
-	return &Client{signer: signer, chains: chains}
+	return &Client{signer: signer, chains: chains, sequencers: make(map[string]*sequencer)}
 }
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,15 +10,26 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"google.golang.org/grpc"
 
+	"github.com/cosmos/cosmos-sdk/codec"
 	codectypes "github.com/cosmos/cosmos-sdk/codec/types"
 	crypto "github.com/cosmos/cosmos-sdk/crypto/codec"
+	"github.com/cosmos/cosmos-sdk/std"
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	sdkerrors "github.com/cosmos/cosmos-sdk/types/errors"
 	"github.com/cosmos/cosmos-sdk/types/tx"
 	"github.com/cosmos/cosmos-sdk/types/tx/signing"
 	auth "github.com/cosmos/cosmos-sdk/x/auth/types"
 	vesting "github.com/cosmos/cosmos-sdk/x/auth/vesting/types"
+	"github.com/cosmos/cosmos-sdk/x/authz"
+	bank "github.com/cosmos/cosmos-sdk/x/bank/types"
+	distribution "github.com/cosmos/cosmos-sdk/x/distribution/types"
+	staking "github.com/cosmos/cosmos-sdk/x/staking/types"
 )
 
+// ErrTimeout is returned by Send when the context deadline passes before the transaction
+// is included in a block. The transaction may still be included later.
+var ErrTimeout = errors.New("timed out waiting for transaction")
+
 func WithGranter(granter string) SendOptionsFn {
 	return func(opts SendOptions) SendOptions {
 		opts.Granter = granter
@@ -39,17 +51,42 @@ func WithPubKey() SendOptionsFn {
 	}
 }
 
-func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn) (*sdk.TxResponse, error) {
+// Send signs the msgs with the keys of their signers and broadcasts them as a single
+// transaction on the chain with the given id. It blocks until the transaction is included
+// in a block or the context is done, in which case ErrTimeout is returned if the deadline
+// passed after the transaction was broadcast.
+func (c *Client) Send(ctx context.Context, chainID string, msgs []sdk.Msg, opts ...SendOptionsFn) (*sdk.TxResponse, error) {
+	resp, _, err := c.send(ctx, chainID, msgs, false, opts...)
+	return resp, err
+}
+
+// SimulateResult is the outcome of simulating a transaction. Msgs are decoded from the
+// signed transaction and JSON is its body as it would have been broadcast.
+type SimulateResult struct {
+	GasWanted uint64
+	GasUsed   uint64
+	Msgs      []sdk.Msg
+	JSON      []byte
+}
+
+// Simulate signs the msgs exactly like Send but only simulates the transaction instead
+// of broadcasting it. The cached account sequences are left untouched.
+func (c *Client) Simulate(ctx context.Context, chainID string, msgs []sdk.Msg, opts ...SendOptionsFn) (*SimulateResult, error) {
+	_, result, err := c.send(ctx, chainID, msgs, true, opts...)
+	return result, err
+}
+
+func (c *Client) send(ctx context.Context, chainID string, msgs []sdk.Msg, simulate bool, opts ...SendOptionsFn) (*sdk.TxResponse, *SimulateResult, error) {
 	anyMsgs := make([]*codectypes.Any, len(msgs))
 	for idx, msg := range msgs {
 		err := msg.ValidateBasic()
 		if err != nil {
-			return nil, fmt.Errorf("invalid msg (idx: %d): %w", idx, err)
+			return nil, nil, fmt.Errorf("invalid msg (idx: %d): %w", idx, err)
 		}
 
 		anyMsgs[idx], err = codectypes.NewAnyWithValue(msg)
 		if err != nil {
-			return nil, fmt.Errorf("msg (idx: %d): %w", idx, err)
+			return nil, nil, fmt.Errorf("msg (idx: %d): %w", idx, err)
 		}
 	}
 
@@ -63,15 +100,15 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 		AuthInfo: &tx.AuthInfo{Fee: &tx.Fee{}},
 	}
 	signers := Tx.GetSigners()
-	chain, err := c.chains.FindChainFromAddress(signers[0].String())
+	chain, err := c.chains.FindChainById(chainID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, signer := range signers {
 		_, err := c.signer.KeyByAddress(signer)
 		if err != nil {
-			return nil, fmt.Errorf("checking keys: %w", err)
+			return nil, nil, fmt.Errorf("checking keys: %w", err)
 		}
 	}
 
@@ -93,22 +130,48 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 	)
 	defer conn.Close()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	// hold the sequencer of the chain until the transaction is in the mempool
+	signerAddrs := make([]string, len(signers))
+	for idx, signer := range signers {
+		signerAddrs[idx] = signer.String()
 	}
+	seq := c.sequencer(chain.Id)
+	seq.mtx.Lock()
+	// other sends may have held the sequencer until the context ended
+	if err := ctx.Err(); err != nil {
+		seq.mtx.Unlock()
+		return nil, nil, err
+	}
+	locked := true
+	unlock := func() {
+		if locked {
+			seq.mtx.Unlock()
+			locked = false
+		}
+	}
+	defer unlock()
 
 	accountQuerier := auth.NewQueryClient(conn)
 	signerInfos := make([]*tx.SignerInfo, len(signers))
 	accountNumbers := make([]uint64, len(signers))
 	for idx, signer := range signers {
-		acc, err := accountQuerier.Account(ctx, &auth.QueryAccountRequest{Address: signer.String()})
-		if err != nil {
-			return nil, fmt.Errorf("retrieving account info for %d: %w", signer, err)
-		}
+		info, ok := seq.lookup(signerAddrs[idx])
+		if !ok {
+			acc, err := accountQuerier.Account(ctx, &auth.QueryAccountRequest{Address: signerAddrs[idx]})
+			if err != nil {
+				return nil, nil, fmt.Errorf("retrieving account info for %s: %w", signerAddrs[idx], err)
+			}
 
-		var account auth.AccountI
-		err = registry.UnpackAny(acc.Account, &account)
-		if err != nil {
-			return nil, fmt.Errorf("unmarshal account: %w", err)
+			var account auth.AccountI
+			err = registry.UnpackAny(acc.Account, &account)
+			if err != nil {
+				return nil, nil, fmt.Errorf("unmarshal account: %w", err)
+			}
+			info = accountInfo{number: account.GetAccountNumber(), sequence: account.GetSequence()}
+			seq.store(signerAddrs[idx], info)
 		}
 
 		signerInfos[idx] = &tx.SignerInfo{
@@ -117,18 +180,18 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 					Single: &tx.ModeInfo_Single{Mode: signing.SignMode_SIGN_MODE_DIRECT},
 				},
 			},
-			Sequence: account.GetSequence(),
+			Sequence: info.sequence,
 		}
 		if options.PubKey {
 			info, _ := c.signer.KeyByAddress(signer)
 			pk := info.GetPubKey()
 			pkAny, err := codectypes.NewAnyWithValue(pk)
 			if err != nil {
-				return nil, fmt.Errorf("get pub key: %w", err)
+				return nil, nil, fmt.Errorf("get pub key: %w", err)
 			}
 			signerInfos[idx].PublicKey = pkAny
 		}
-		accountNumbers[idx] = account.GetAccountNumber()
+		accountNumbers[idx] = info.number
 	}
 
 	Tx.AuthInfo.SignerInfos = signerInfos
@@ -138,13 +201,13 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 	// TODO: add gas estimation that ideally doesn't require signing (soft estimation)
 	// txBytes, err := Tx.Marshal()
 	// if err != nil {
-	// 	return nil, err
+	// 	return nil, nil, err
 	// }
 	// simresp, err := txClient.Simulate(ctx, &tx.SimulateRequest{
 	// 	TxBytes: txBytes,
 	// })
 	// if err != nil {
-	// 	return nil, err
+	// 	return nil, nil, err
 	// }
 
 	Tx.AuthInfo.Fee = &tx.Fee{GasLimit: 2000000}
@@ -159,11 +222,11 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 
 	bodyBytes, err := Tx.Body.Marshal()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	authInfoBytes, err := Tx.AuthInfo.Marshal()
 	if err != nil {
-		return nil, fmt.Errorf("marshal auth info: %w", err)
+		return nil, nil, fmt.Errorf("marshal auth info: %w", err)
 	}
 	signatures := make([][]byte, len(signers))
 	for idx, signer := range signers {
@@ -175,12 +238,12 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 		}
 		signedBytes, err := signDoc.Marshal()
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		sig, _, err := c.signer.SignByAddress(signer, signedBytes)
 		if err != nil {
-			return nil, fmt.Errorf("failed to sign message: %w", err)
+			return nil, nil, fmt.Errorf("failed to sign message: %w", err)
 		}
 		signatures[idx] = sig
 	}
@@ -188,7 +251,7 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 	Tx.Signatures = signatures
 
 	if err := Tx.ValidateBasic(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	raw := &tx.TxRaw{
@@ -198,38 +261,107 @@ func (c *Client) Send(ctx context.Context, msgs []sdk.Msg, opts ...SendOptionsFn
 	}
 	txBytes, err := proto.Marshal(raw)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	if simulate {
+		simResp, err := txClient.Simulate(ctx, &tx.SimulateRequest{TxBytes: txBytes})
+		if err != nil {
+			// the simulation may have failed on a stale sequence
+			seq.reset(signerAddrs)
+			return nil, nil, fmt.Errorf("simulating transaction: %w", err)
+		}
+		result, err := decodeSimulation(bodyBytes, simResp)
+		return nil, result, err
 	}
 
 	txResp, err := txClient.BroadcastTx(ctx, &tx.BroadcastTxRequest{
 		TxBytes: txBytes,
 		Mode:    tx.BroadcastMode_BROADCAST_MODE_SYNC,
 	})
-	if err != nil {
-		return nil, err
+	switch {
+	case err != nil:
+		// it is unknown whether the transaction made it into the mempool
+		seq.reset(signerAddrs)
+		return nil, nil, err
+	case txResp.TxResponse.Code == sdkerrors.ErrWrongSequence.ABCICode() && txResp.TxResponse.Codespace == sdkerrors.RootCodespace:
+		seq.reset(signerAddrs)
+	case txResp.TxResponse.Code == 0:
+		seq.increment(signerAddrs)
 	}
+	unlock()
 
 	if txResp.TxResponse.Code != 0 {
-		return txResp.TxResponse, nil
+		return txResp.TxResponse, nil, nil
 	}
 
+	hash := txResp.TxResponse.TxHash
 	for {
 		select {
 		case <-time.After(time.Millisecond * 100):
-			resTx, err := txClient.GetTx(ctx, &tx.GetTxRequest{Hash: txResp.TxResponse.TxHash})
+			resTx, err := txClient.GetTx(ctx, &tx.GetTxRequest{Hash: hash})
 			if err != nil {
+				if ctx.Err() != nil {
+					return nil, nil, waitError(ctx, hash)
+				}
 				if strings.Contains(err.Error(), "tx not found") {
 					// retry
 					continue
 				}
-				return nil, err
+				return nil, nil, err
 			}
-			return resTx.TxResponse, nil
+			return resTx.TxResponse, nil, nil
 
 		case <-ctx.Done():
-			return nil, nil
+			return nil, nil, waitError(ctx, hash)
+		}
+	}
+}
+
+// waitError describes why waiting for a broadcast transaction ended early
+func waitError(ctx context.Context, hash string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w %s", ErrTimeout, hash)
+	}
+	return fmt.Errorf("waiting for transaction %s: %w", hash, ctx.Err())
+}
+
+// decodeSimulation decodes the messages of a simulated transaction body
+func decodeSimulation(bodyBytes []byte, resp *tx.SimulateResponse) (*SimulateResult, error) {
+	cdc := codec.NewProtoCodec(msgRegistry())
+	var body tx.TxBody
+	if err := cdc.Unmarshal(bodyBytes, &body); err != nil {
+		return nil, fmt.Errorf("decoding simulated transaction: %w", err)
+	}
+	result := &SimulateResult{Msgs: make([]sdk.Msg, len(body.Messages))}
+	for idx, msg := range body.Messages {
+		decoded, ok := msg.GetCachedValue().(sdk.Msg)
+		if !ok {
+			return nil, fmt.Errorf("decoding simulated transaction: message %d of type %s is not unpacked", idx, msg.TypeUrl)
 		}
+		result.Msgs[idx] = decoded
+	}
+	if resp.GasInfo != nil {
+		result.GasWanted = resp.GasInfo.GasWanted
+		result.GasUsed = resp.GasInfo.GasUsed
 	}
+	var err error
+	result.JSON, err = cdc.MarshalJSON(&body)
+	if err != nil {
+		return nil, fmt.Errorf("encoding simulated transaction: %w", err)
+	}
+	return result, nil
+}
+
+// msgRegistry knows every message sent by the stakebot
+func msgRegistry() codectypes.InterfaceRegistry {
+	registry := codectypes.NewInterfaceRegistry()
+	std.RegisterInterfaces(registry)
+	authz.RegisterInterfaces(registry)
+	bank.RegisterInterfaces(registry)
+	distribution.RegisterInterfaces(registry)
+	staking.RegisterInterfaces(registry)
+	return registry
 }
 
 type SendOptionsFn func(opts SendOptions) SendOptions
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"sync"
+)
+
+// sequencer serializes the transactions signed on a chain so that concurrent sends do
+// not reuse an account sequence. The sequence of each signer is cached between sends so
+// that a transaction can be broadcast before the previous one is included in a block.
+type sequencer struct {
+	mtx      sync.Mutex
+	accounts map[string]accountInfo
+}
+
+type accountInfo struct {
+	number   uint64
+	sequence uint64
+}
+
+// sequencer returns the sequencer of a chain
+func (c *Client) sequencer(chainID string) *sequencer {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	seq, ok := c.sequencers[chainID]
+	if !ok {
+		seq = &sequencer{accounts: make(map[string]accountInfo)}
+		c.sequencers[chainID] = seq
+	}
+	return seq
+}
+
+// lookup returns the cached account of a signer. Must be called with the lock held.
+func (s *sequencer) lookup(signer string) (accountInfo, bool) {
+	info, ok := s.accounts[signer]
+	return info, ok
+}
+
+// store caches the account of a signer. Must be called with the lock held.
+func (s *sequencer) store(signer string, info accountInfo) {
+	s.accounts[signer] = info
+}
+
+// increment advances the cached sequence of the signers after a transaction was
+// accepted into the mempool. Must be called with the lock held.
+func (s *sequencer) increment(signers []string) {
+	for _, signer := range signers {
+		info := s.accounts[signer]
+		info.sequence++
+		s.accounts[signer] = info
+	}
+}
+
+// reset drops the cached accounts of the signers so that they are queried again. Must
+// be called with the lock held.
+func (s *sequencer) reset(signers []string) {
+	for _, signer := range signers {
+		delete(s.accounts, signer)
+	}
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func init() {
+	var backupCmd = &cobra.Command{
+		Use:   "backup [file]",
+		Short: "Download a backup of a running server",
+		Long:  "Streams a consistent snapshot of the store of a running server using the admin token of the config. Only the badger store supports backups.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			resp, err := adminRequest(http.MethodGet, "/v1/admin/backup", nil)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			f, err := os.Create(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			n, err := io.Copy(f, resp.Body)
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Wrote %d bytes to %s\n", n, args[0])
+			return nil
+		},
+	}
+	rootCmd.AddCommand(backupCmd)
+
+	var restoreCmd = &cobra.Command{
+		Use:   "restore [file]",
+		Short: "Load a backup into the store",
+		Long:  "Validates every record and event of a backup against the configured chains and then writes them to the store, overwriting existing records. The server must be stopped while restoring.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return withStore(func(db store.Store, config types.Config) error {
+				counts, err := store.Restore(db, f, config.Chains)
+				if err != nil {
+					return err
+				}
+				cmd.Printf("Restored %d records, %d events and %d paused chains\n", counts.Records, counts.Events, counts.PausedChains)
+				return nil
+			})
+		},
+	}
+	rootCmd.AddCommand(restoreCmd)
+}
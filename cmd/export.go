@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+const formatFlagUsage = "Encoding of the file: json (one entry per line) or proto (length delimited)"
+
+func init() {
+	var exportFormat string
+	var exportCmd = &cobra.Command{
+		Use:   "export [file]",
+		Short: "Write all records and history to a file",
+		Long:  "Writes all records followed by all history to the file, or stdout if no file is given. The server must be stopped when using the badger store, use the admin backup endpoint instead to back up a running server.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return withStore(func(db store.Store, _ types.Config) error {
+				var w io.Writer = cmd.OutOrStdout()
+				if len(args) == 1 {
+					f, err := os.Create(args[0])
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+					w = f
+				}
+				counts, err := store.Export(db, w, exportFormat)
+				if err != nil {
+					return err
+				}
+				cmd.PrintErrf("Exported %d records, %d events and %d paused chains\n", counts.Records, counts.Events, counts.PausedChains)
+				return nil
+			})
+		},
+	}
+	exportCmd.Flags().StringVar(&exportFormat, "format", store.FormatJSON, formatFlagUsage)
+	rootCmd.AddCommand(exportCmd)
+
+	var importFormat string
+	var importCmd = &cobra.Command{
+		Use:   "import [file]",
+		Short: "Load records and history from an export",
+		Long:  "Validates every entry of the export against the configured chains and then writes them to the store, overwriting existing records. The server must be stopped while importing.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return withStore(func(db store.Store, config types.Config) error {
+				counts, err := store.Import(db, f, importFormat, config.Chains)
+				if err != nil {
+					return err
+				}
+				cmd.Printf("Imported %d records, %d events and %d paused chains\n", counts.Records, counts.Events, counts.PausedChains)
+				return nil
+			})
+		},
+	}
+	importCmd.Flags().StringVar(&importFormat, "format", store.FormatJSON, formatFlagUsage)
+	rootCmd.AddCommand(importCmd)
+}
+
+// withStore opens the configured store, checks its version and calls fn with it
+func withStore(fn func(store.Store, types.Config) error) error {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+
+	rootDir := filepath.Join(homeDir, defaultDir)
+	config, err := types.LoadConfig(filepath.Join(rootDir, defaultConfigFileName))
+	if err != nil {
+		return err
+	}
+
+	db, err := store.New(rootDir, config.Store)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := store.CheckVersion(db); err != nil {
+		return err
+	}
+	return fn(db, config)
+}
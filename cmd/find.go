@@ -5,16 +5,19 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	v1 "github.com/plural-labs/stakebot/router/v1"
 	"github.com/plural-labs/stakebot/types"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	findCmd.Flags().String(chainFlag, "", chainFlagUsage)
 	rootCmd.AddCommand(findCmd)
 }
 
@@ -39,7 +42,13 @@ var findCmd = &cobra.Command{
 			addr = "http://" + addr
 		}
 
-		resp, err := http.Get(fmt.Sprintf("%s/v1/status?address=%s", addr, args[0]))
+		query := url.Values{}
+		query.Set("address", args[0])
+		if chainID, _ := cmd.Flags().GetString(chainFlag); chainID != "" {
+			query.Set("chain_id", chainID)
+		}
+
+		resp, err := http.Get(fmt.Sprintf("%s/v1/status?%s", addr, query.Encode()))
 		if err != nil {
 			return err
 		}
@@ -51,21 +60,98 @@ var findCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		var record types.Record
-		err = json.Unmarshal(respBytes, &record)
+		var status v1.StatusResponse
+		err = json.Unmarshal(respBytes, &status)
 		if err != nil {
 			return err
 		}
+		if status.Record == nil {
+			return fmt.Errorf("unexpected response: %s", respBytes)
+		}
+		record := status.Record
 
 		cmd.Printf(`Status:
+Chain: %s
 Address: %s
 Tolerance: %d
-Frequency: %s
+Schedule: %s
 Last Restaked: %s
 Total Rewards Restaked: %d
-Errors: %s
-`, record.Address, record.Tolerance, types.Frequency_name[int32(record.Frequency)], time.Unix(record.LastUpdatedUnixTime, 0).String(), record.TotalAutostakedRewards, record.ErrorLogs)
+Failing: %t
+Consecutive Failures: %d
+Suspended: %t
+Paused: %t
+Chain Paused: %t
+`, record.ChainId, record.Address, record.Tolerance, scheduleName(record), time.Unix(record.LastUpdatedUnixTime, 0).String(), record.TotalAutostakedRewards, record.Failing(), record.ConsecutiveFailures, record.Suspended, record.Paused, status.ChainPaused)
+
+		if record.MinRewards != nil {
+			cmd.Printf("Min Rewards: %d\n", record.GetMinRewards())
+		}
+		if record.MinRewardsFeeMultiple != nil {
+			cmd.Printf("Min Rewards Fee Multiple: %g\n", record.GetMinRewardsFeeMultiple())
+		}
+		if record.Type == types.RecordType_VALIDATOR_OPERATOR {
+			cmd.Printf("Type: %s\nTotal Commission Restaked: %d\n", types.RecordType_name[int32(record.Type)], record.TotalAutostakedCommission)
+		}
+		cmd.Printf("Allocation: %s\n", allocationName(record))
+		cmd.Printf("Unhealthy Validators: %s\n", types.UnhealthyPolicy_name[int32(record.UnhealthyPolicy)])
+
+		nextRun := "not scheduled"
+		if status.NextRun != nil {
+			nextRun = status.NextRun.Local().String()
+		}
+		cmd.Printf("Next Run: %s\n", nextRun)
+		if last := record.LastRun; last != nil {
+			lastRun := fmt.Sprintf("%s %s in %s", time.Unix(last.UnixTime, 0).String(), types.Outcome_name[int32(last.Outcome)], time.Duration(last.DurationMs)*time.Millisecond)
+			if last.Outcome == types.Outcome_FAILURE {
+				lastRun += fmt.Sprintf(" (%s)", types.ErrorCode_name[int32(last.ErrorCode)])
+			}
+			cmd.Printf("Last Run: %s\n", lastRun)
+		}
+
+		if len(record.Validators) > 0 {
+			cmd.Println("Validators:")
+			for _, validator := range record.Validators {
+				cmd.Printf("    %s claimed=%d delegated=%d\n", validator.ValidatorAddress, validator.Claimed, validator.Delegated)
+			}
+		}
+
+		if len(record.Errors) > 0 {
+			cmd.Println("Recent Errors:")
+			// most recent first
+			for i := len(record.Errors) - 1; i >= 0; i-- {
+				entry := record.Errors[i]
+				cmd.Printf("    %s %s %s\n", time.Unix(entry.UnixTime, 0).Format(time.RFC3339), types.ErrorCode_name[int32(entry.Code)], entry.Message)
+			}
+		}
 
 		return nil
 	},
 }
+
+// scheduleName is the custom schedule of a record or else the name of its frequency
+func scheduleName(record *types.Record) string {
+	if record.Schedule != "" {
+		return record.Schedule
+	}
+	if record.Frequency == types.Frequency_AUTO && record.AutoIntervalSeconds > 0 {
+		return fmt.Sprintf("AUTO (every %s)", time.Duration(record.AutoIntervalSeconds)*time.Second)
+	}
+	return types.Frequency_name[int32(record.Frequency)]
+}
+
+func allocationName(record *types.Record) string {
+	name := types.AllocationStrategy_name[int32(record.Allocation)]
+	switch record.Allocation {
+	case types.AllocationStrategy_SINGLE_VALIDATOR:
+		return fmt.Sprintf("%s (%s)", name, record.AllocationValidator)
+	case types.AllocationStrategy_TARGET_WEIGHTS:
+		weights := make([]string, len(record.AllocationWeights))
+		for idx, weight := range record.AllocationWeights {
+			weights[idx] = fmt.Sprintf("%s:%g", weight.ValidatorAddress, weight.Weight)
+		}
+		return fmt.Sprintf("%s (%s)", name, strings.Join(weights, ", "))
+	default:
+		return name
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+func init() {
+	var (
+		from, to, chainID string
+		limit             int
+	)
+	var historyCmd = &cobra.Command{
+		Use:   "history [address]",
+		Short: "Page through the restake history of an address",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+
+			filePath := filepath.Join(homeDir, defaultDir, defaultConfigFileName)
+			config, err := types.LoadConfig(filePath)
+			if err != nil {
+				return err
+			}
+
+			addr := config.ListenAddr
+			if !strings.Contains(config.ListenAddr, "://") {
+				addr = "http://" + addr
+			}
+
+			query := url.Values{}
+			query.Set("address", args[0])
+			query.Set("limit", strconv.Itoa(limit))
+			if chainID != "" {
+				query.Set("chain_id", chainID)
+			}
+			if from != "" {
+				query.Set("from", from)
+			}
+			if to != "" {
+				query.Set("to", to)
+			}
+
+			resp, err := http.Get(fmt.Sprintf("%s/v1/history?%s", addr, query.Encode()))
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != 200 {
+				return fmt.Errorf("Received unexpected code %d from url", resp.StatusCode)
+			}
+
+			respBytes, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			var events []*types.Event
+			err = json.Unmarshal(respBytes, &events)
+			if err != nil {
+				return err
+			}
+
+			if len(events) == 0 {
+				cmd.Printf("No events found for %s\n", args[0])
+				return nil
+			}
+
+			for _, event := range events {
+				printEvent(cmd, event)
+			}
+
+			if len(events) == limit {
+				next := time.Unix(0, events[len(events)-1].UnixNanoTime+1)
+				cmd.Printf("\nMore events available, continue with --from %s\n", next.Format(time.RFC3339Nano))
+			}
+
+			return nil
+		},
+	}
+	historyCmd.Flags().StringVar(&from, "from", "", "Only show events at or after this time (unix seconds or RFC3339)")
+	historyCmd.Flags().StringVar(&to, "to", "", "Only show events before this time (unix seconds or RFC3339)")
+	historyCmd.Flags().StringVar(&chainID, chainFlag, "", chainFlagUsage)
+	historyCmd.Flags().IntVar(&limit, "limit", 20, "Number of events per page")
+	rootCmd.AddCommand(historyCmd)
+}
+
+func printEvent(cmd *cobra.Command, event *types.Event) {
+	cmd.Printf("%s %s rewards=%d fee=%d duration=%dms",
+		time.Unix(0, event.UnixNanoTime).Format(time.RFC3339),
+		types.Outcome_name[int32(event.Outcome)],
+		event.TotalRewards,
+		event.Fee,
+		event.DurationMs,
+	)
+	if event.Commission != 0 {
+		cmd.Printf(" commission=%d", event.Commission)
+	}
+	if event.TxHash != "" {
+		cmd.Printf(" tx=%s height=%d", event.TxHash, event.Height)
+	}
+	if event.Outcome == types.Outcome_SKIPPED {
+		cmd.Printf(" reason=%s", types.SkipReason_name[int32(event.SkipReason)])
+	}
+	if event.Outcome == types.Outcome_SIMULATED {
+		cmd.Printf(" gas=%d", event.GasUsed)
+	}
+	if event.Error != "" {
+		cmd.Printf(" code=%s error=%q", types.ErrorCode_name[int32(event.ErrorCode)], event.Error)
+	}
+	cmd.Println()
+	for _, validator := range event.Validators {
+		cmd.Printf("    %s claimed=%d delegated=%d\n", validator.ValidatorAddress, validator.Claimed, validator.Delegated)
+	}
+	for _, validator := range event.UnhealthyValidators {
+		cmd.Printf("    %s %s withheld=%d policy=%s\n",
+			validator.ValidatorAddress,
+			types.ValidatorHealth_name[int32(validator.Health)],
+			validator.Withheld,
+			types.UnhealthyPolicy_name[int32(event.UnhealthyPolicy)],
+		)
+	}
+}
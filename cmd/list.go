@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	v1 "github.com/plural-labs/stakebot/router/v1"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func init() {
+	var (
+		chainID, frequency, hasError        string
+		updatedAfter, updatedBefore, cursor string
+		output                              string
+		limit                               int
+	)
+	var listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List the registered addresses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unknown output format %s, expected table or json", output)
+			}
+
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+
+			filePath := filepath.Join(homeDir, defaultDir, defaultConfigFileName)
+			config, err := types.LoadConfig(filePath)
+			if err != nil {
+				return err
+			}
+
+			addr := config.ListenAddr
+			if !strings.Contains(config.ListenAddr, "://") {
+				addr = "http://" + addr
+			}
+
+			query := url.Values{}
+			query.Set("limit", strconv.Itoa(limit))
+			for key, value := range map[string]string{
+				"chain_id":       chainID,
+				"frequency":      frequency,
+				"has_error":      hasError,
+				"updated_after":  updatedAfter,
+				"updated_before": updatedBefore,
+				"cursor":         cursor,
+			} {
+				if value != "" {
+					query.Set(key, value)
+				}
+			}
+
+			resp, err := http.Get(fmt.Sprintf("%s/v1/records?%s", addr, query.Encode()))
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			respBytes, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			if resp.StatusCode != 200 {
+				return fmt.Errorf("Received unexpected code %d from url: %s", resp.StatusCode, respBytes)
+			}
+			var page v1.RecordsResponse
+			err = json.Unmarshal(respBytes, &page)
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				bz, err := json.MarshalIndent(page, "", "  ")
+				if err != nil {
+					return err
+				}
+				cmd.Println(string(bz))
+				return nil
+			}
+
+			if len(page.Records) == 0 {
+				cmd.Println("No records found")
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "CHAIN\tADDRESS\tSCHEDULE\tTOLERANCE\tLAST RESTAKED\tTOTAL RESTAKED\tLAST ERROR")
+			for _, record := range page.Records {
+				lastError := ""
+				if record.Failing() {
+					lastError = types.ErrorCode_name[int32(record.LastError().Code)]
+				}
+				if record.Suspended {
+					lastError += " (suspended)"
+				}
+				lastRestaked := "never"
+				if record.LastUpdatedUnixTime != 0 {
+					lastRestaked = time.Unix(record.LastUpdatedUnixTime, 0).Format(time.RFC3339)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%d\t%s\n",
+					record.ChainId,
+					record.Address,
+					scheduleName(record),
+					record.Tolerance,
+					lastRestaked,
+					record.TotalAutostakedRewards,
+					lastError,
+				)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if page.Next != "" {
+				cmd.Printf("\nMore records available, continue with --cursor %s\n", page.Next)
+			}
+
+			return nil
+		},
+	}
+	listCmd.Flags().StringVar(&chainID, chainFlag, "", "Only list records of this chain id")
+	listCmd.Flags().StringVar(&frequency, "frequency", "", "Only list records restaked at this frequency (e.g. daily)")
+	listCmd.Flags().StringVar(&hasError, "has-error", "", "Only list records with (true) or without (false) errors")
+	listCmd.Flags().StringVar(&updatedAfter, "updated-after", "", "Only list records last restaked at or after this time (unix seconds or RFC3339)")
+	listCmd.Flags().StringVar(&updatedBefore, "updated-before", "", "Only list records last restaked before this time (unix seconds or RFC3339)")
+	listCmd.Flags().StringVar(&cursor, "cursor", "", "Continue a previous listing")
+	listCmd.Flags().IntVar(&limit, "limit", 50, "Number of records per page")
+	listCmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+	rootCmd.AddCommand(listCmd)
+}
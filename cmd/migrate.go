@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func init() {
+	var dryRun bool
+	var migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade the store to the schema version of this binary",
+		Long:  "Runs all pending migrations in order. The server must be stopped while migrating.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
+			}
+
+			rootDir := filepath.Join(homeDir, defaultDir)
+			config, err := types.LoadConfig(filepath.Join(rootDir, defaultConfigFileName))
+			if err != nil {
+				return err
+			}
+
+			store, err := store.New(rootDir, config.Store)
+			if err != nil {
+				return err
+			}
+			defer store.Close()
+
+			version, err := store.Version()
+			if err != nil {
+				return err
+			}
+			cmd.Printf("Store is at version %d\n", version)
+
+			migrations, err := store.Migrate(config.Chains, dryRun)
+			for _, m := range migrations {
+				if dryRun {
+					cmd.Printf("Would apply migration %d: %s\n", m.Version, m.Description)
+				} else {
+					cmd.Printf("Applied migration %d: %s\n", m.Version, m.Description)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			if len(migrations) == 0 {
+				cmd.Printf("Store is up to date\n")
+			}
+
+			return nil
+		},
+	}
+	migrateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Run the migrations without committing any changes")
+	rootCmd.AddCommand(migrateCmd)
+}
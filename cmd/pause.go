@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	v1 "github.com/plural-labs/stakebot/router/v1"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func init() {
+	rootCmd.AddCommand(pauseCommand(true), pauseCommand(false))
+}
+
+// pauseCommand returns the pause or the resume command
+func pauseCommand(paused bool) *cobra.Command {
+	name, verb, endpoint := "pause", "Pause", "/v1/admin/pause"
+	if !paused {
+		name, verb, endpoint = "resume", "Resume", "/v1/admin/resume"
+	}
+	cmd := &cobra.Command{
+		Use:   name + " [address]",
+		Short: verb + " restaking an address or a whole chain",
+		Long:  verb + "s restaking the address or, without an address, every address of the chain given by --chain on a running server. Paused addresses keep their settings and history.",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			query := url.Values{}
+			if chainID, _ := cmd.Flags().GetString(chainFlag); chainID != "" {
+				query.Set("chain_id", chainID)
+			}
+			if len(args) == 1 {
+				query.Set("address", args[0])
+			} else if query.Get("chain_id") == "" {
+				return fmt.Errorf("either an address or --%s is required", chainFlag)
+			}
+
+			resp, err := adminRequest(http.MethodPost, endpoint, query)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			respBytes, err := ioutil.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 {
+				var paused v1.PausedResponse
+				if err := json.Unmarshal(respBytes, &paused); err != nil {
+					return err
+				}
+				cmd.Printf("Paused chains: %s\n", strings.Join(paused.PausedChains, ", "))
+				return nil
+			}
+			var record types.Record
+			if err := json.Unmarshal(respBytes, &record); err != nil {
+				return err
+			}
+			cmd.Printf("Address %s on %s paused: %t\n", record.Address, record.ChainId, record.Paused)
+			return nil
+		},
+	}
+	cmd.Flags().String(chainFlag, "", "Chain id of the address, or of the chain to "+name+" when no address is given")
+	return cmd
+}
+
+// adminRequest sends a request to an admin endpoint of the running server using the
+// admin token of the config. Responses other than 200 are returned as errors.
+func adminRequest(method, path string, query url.Values) (*http.Response, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	filePath := filepath.Join(homeDir, defaultDir, defaultConfigFileName)
+	config, err := types.LoadConfig(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if config.AdminToken == "" {
+		return nil, fmt.Errorf("no admin_token set in the config")
+	}
+
+	addr := config.ListenAddr
+	if !strings.Contains(config.ListenAddr, "://") {
+		addr = "http://" + addr
+	}
+	target := addr + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+config.AdminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		defer resp.Body.Close()
+		respBytes, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Received unexpected code %d from url: %s", resp.StatusCode, respBytes)
+	}
+	return resp, nil
+}
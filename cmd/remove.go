@@ -7,9 +7,11 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
 )
 
 func init() {
+	removeCmd.Flags().String(chainFlag, "", chainFlagUsage)
 	rootCmd.AddCommand(removeCmd)
 }
 
@@ -24,17 +26,28 @@ var removeCmd = &cobra.Command{
 		}
 
 		rootDir := filepath.Join(homeDir, defaultDir)
+		config, err := types.LoadConfig(filepath.Join(rootDir, defaultConfigFileName))
+		if err != nil {
+			return err
+		}
+
+		chainID, _ := cmd.Flags().GetString(chainFlag)
+		chain, err := config.Chains.FindChain(chainID, args[0])
+		if err != nil {
+			return err
+		}
 
-		store, err := store.New(rootDir)
+		store, err := store.New(rootDir, config.Store)
 		if err != nil {
 			return err
 		}
+		defer store.Close()
 
-		if err := store.DeleteRecord(args[0]); err != nil {
+		if err := store.DeleteRecord(chain.Id, args[0]); err != nil {
 			return err
 		}
 
-		cmd.Printf("Removed address %s from store\n", args[0])
+		cmd.Printf("Removed address %s on %s from store\n", args[0], chain.Id)
 
 		return nil
 	},
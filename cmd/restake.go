@@ -5,17 +5,20 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
-	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/plural-labs/stakebot/types"
 	"github.com/spf13/cobra"
 )
 
 func init() {
-	var tolerance int64
+	var (
+		tolerance int64
+		chainID   string
+	)
 	var restakeCmd = &cobra.Command{
 		Use:   "restake [address]",
 		Short: "manually restakes the tokens of a registered address",
@@ -32,14 +35,9 @@ func init() {
 				return err
 			}
 
-			userAddress, err := sdk.AccAddressFromBech32(args[0])
+			chain, err := config.Chains.FindChain(chainID, args[0])
 			if err != nil {
-				return err
-			}
-
-			_, err = config.Chains.FindChainFromAddress(userAddress.String())
-			if err != nil {
-				return fmt.Errorf("autostakebot does not support chain with address %s", userAddress.String())
+				return fmt.Errorf("autostakebot does not support chain with address %s: %w", args[0], err)
 			}
 
 			addr := config.ListenAddr
@@ -47,7 +45,7 @@ func init() {
 				addr = "http://" + addr
 			}
 
-			query := fmt.Sprintf("%s/v1/restake?address=%s", addr, userAddress.String())
+			query := fmt.Sprintf("%s/v1/restake?address=%s&chain_id=%s", addr, args[0], url.QueryEscape(chain.Id))
 
 			if tolerance >= 0 {
 				query += fmt.Sprintf("&tolerance=%d", tolerance)
@@ -76,6 +74,7 @@ func init() {
 			return nil
 		},
 	}
+	restakeCmd.Flags().StringVar(&chainID, chainFlag, "", chainFlagUsage)
 	restakeCmd.Flags().Int64Var(&tolerance, "tolerance", -1, "How many native tokens to remain liquid for fees")
 	rootCmd.AddCommand(restakeCmd)
 }
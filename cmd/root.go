@@ -1,20 +1,38 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 )
 
+const (
+	chainFlag      = "chain"
+	chainFlagUsage = "Chain id of the address, only required when several chains share the address prefix"
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "stakebot",
 	Short: "Autostakebot is a cli for running a service to automagically claim and delegate stake on accounts across Cosmos chains",
 }
 
+// exitError is returned by commands that exit with a code other than 1
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e exitError) Error() string { return e.err.Error() }
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		var exit exitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.code)
+		}
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/plural-labs/stakebot/bot"
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+// runFailedExitCode is the exit code of a run in which some restakes failed
+const runFailedExitCode = 2
+
+func init() {
+	var frequency, chainID string
+	var runCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Restake all records of a frequency once and exit",
+		Long: fmt.Sprintf(`Restakes every record of the given frequency and chain once, like the scheduler of a running server would, prints a summary and exits. This allows running the stakebot from an external scheduler such as a Kubernetes CronJob instead of "stakebot serve". Without a frequency all records are restaked, including those with a custom schedule.
+
+The command exits with code %d if any restake failed. The badger store can only be opened by a single process, so the server must not be running at the same time.`, runFailedExitCode),
+		Args: cobra.NoArgs,
+		// failed restakes are reported through the exit code and not a usage error
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter := store.Filter{ChainID: chainID}
+			if frequency != "" {
+				value, ok := types.Frequency_value[strings.ToUpper(frequency)]
+				if !ok || value == int32(types.Frequency_UNKNOWN) {
+					return fmt.Errorf("unknown frequency %s", frequency)
+				}
+				filter.Frequency = types.Frequency(value)
+			}
+			dryRun, _ := cmd.Flags().GetBool(dryRunFlag)
+
+			keyring, err := getKeyring()
+			if err != nil {
+				return err
+			}
+
+			return withStore(func(db store.Store, config types.Config) error {
+				if filter.ChainID != "" {
+					if _, err := config.Chains.FindChainById(filter.ChainID); err != nil {
+						return err
+					}
+				}
+				if dryRun {
+					config.Bot.DryRun = true
+				}
+				stakingBot, err := bot.New(db, keyring, config.Chains, config.Bot)
+				if err != nil {
+					return err
+				}
+
+				// restakes in progress are cancelled and recorded when the process is
+				// stopped, like they are by serve
+				ctx, stop := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
+				defer stop()
+				go func() {
+					<-ctx.Done()
+					stakingBot.Cancel()
+				}()
+
+				summary, err := stakingBot.RunOnce(filter)
+				if summary.Chains != nil {
+					printRunSummary(cmd, summary)
+				}
+				if err != nil {
+					return err
+				}
+
+				total := summary.Total()
+				if total.Failed > 0 {
+					return exitError{
+						code: runFailedExitCode,
+						err:  fmt.Errorf("%d of %d restakes failed", total.Failed, total.Succeeded+total.NoRewards+total.Skipped+total.Simulated+total.Failed),
+					}
+				}
+				return nil
+			})
+		},
+	}
+	runCmd.Flags().StringVar(&frequency, "frequency", "", "Only restake records of this frequency, e.g. daily")
+	runCmd.Flags().StringVar(&chainID, chainFlag, "", "Only restake records of this chain")
+	runCmd.Flags().Bool(dryRunFlag, false, "Simulate restake transactions instead of broadcasting them")
+	rootCmd.AddCommand(runCmd)
+}
+
+func printRunSummary(cmd *cobra.Command, summary bot.RunSummary) {
+	chainIDs := make([]string, 0, len(summary.Chains))
+	for chainID := range summary.Chains {
+		chainIDs = append(chainIDs, chainID)
+	}
+	sort.Strings(chainIDs)
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHAIN\tSUCCEEDED\tNO REWARDS\tSKIPPED\tSIMULATED\tFAILED\tREWARDS")
+	for _, chainID := range chainIDs {
+		chain := summary.Chains[chainID]
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\n", chainID, chain.Succeeded, chain.NoRewards, chain.Skipped, chain.Simulated, chain.Failed, chain.Rewards)
+	}
+	total := summary.Total()
+	fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\t%d\t%d\t\n", total.Succeeded, total.NoRewards, total.Skipped, total.Simulated, total.Failed)
+	w.Flush()
+	cmd.Printf("Completed in %s\n", summary.Duration)
+}
@@ -6,14 +6,19 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
 	"github.com/plural-labs/stakebot/bot"
 	"github.com/plural-labs/stakebot/router"
+	"github.com/plural-labs/stakebot/store"
 	"github.com/plural-labs/stakebot/types"
 )
 
+const dryRunFlag = "dry-run"
+
 func init() {
+	serveCmd.Flags().Bool(dryRunFlag, false, "Simulate restake transactions and store them in the history instead of broadcasting them")
 	rootCmd.AddCommand(serveCmd)
 }
 
@@ -36,7 +41,24 @@ var serveCmd = &cobra.Command{
 			return err
 		}
 
-		stakingBot, err := bot.New(filepath.Join(homeDir, defaultDir), keyring, config.Chains)
+		db, err := store.New(filepath.Join(homeDir, defaultDir), config.Store)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := store.CheckVersion(db); err != nil {
+			return err
+		}
+
+		if dryRun, _ := cmd.Flags().GetBool(dryRunFlag); dryRun {
+			config.Bot.DryRun = true
+		}
+		if config.Bot.DryRun {
+			log.Warn().Msg("Running in dry run mode, restake transactions are simulated and not broadcast")
+		}
+
+		stakingBot, err := bot.New(db, keyring, config.Chains, config.Bot)
 		if err != nil {
 			return err
 		}
@@ -44,12 +66,11 @@ var serveCmd = &cobra.Command{
 		ctx, cancel := signal.NotifyContext(cmd.Context(), syscall.SIGTERM, syscall.SIGINT)
 		defer cancel()
 
-		err = stakingBot.StartJobs()
-		defer stakingBot.StopJobs()
-		if err != nil {
+		if err := stakingBot.StartJobs(); err != nil {
 			return err
 		}
+		defer stakingBot.StopJobs()
 
-		return router.Serve(ctx, config.ListenAddr, stakingBot)
+		return router.Serve(ctx, config.ListenAddr, config.AdminToken, stakingBot)
 	},
 }
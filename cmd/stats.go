@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show storage statistics of the server",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+
+		filePath := filepath.Join(homeDir, defaultDir, defaultConfigFileName)
+		config, err := types.LoadConfig(filePath)
+		if err != nil {
+			return err
+		}
+
+		addr := config.ListenAddr
+		if !strings.Contains(config.ListenAddr, "://") {
+			addr = "http://" + addr
+		}
+
+		resp, err := http.Get(fmt.Sprintf("%s/v1/stats", addr))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != 200 {
+			return fmt.Errorf("Received unexpected code %d from url", resp.StatusCode)
+		}
+
+		respBytes, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var stats store.Stats
+		err = json.Unmarshal(respBytes, &stats)
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf(`Backend: %s
+Records: %d
+Events: %d
+`, stats.Backend, stats.Records, stats.Events)
+		if stats.Backend == types.StoreBackendBadger {
+			lastGC := "never"
+			if !stats.LastGC.IsZero() {
+				lastGC = stats.LastGC.Format(time.RFC3339)
+			}
+			cmd.Printf(`LSM Size: %s
+Value Log Size: %s
+Last GC: %s
+GC Rewrites: %d
+`, formatBytes(stats.LSMSize), formatBytes(stats.ValueLogSize), lastGC, stats.GCRewrites)
+		}
+
+		return nil
+	},
+}
+
+// formatBytes prints a size in the largest binary unit it fills
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
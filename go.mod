@@ -5,6 +5,8 @@ go 1.17
 require (
 	github.com/cosmos/cosmos-sdk v0.45.0
 	github.com/dgraph-io/badger/v3 v3.2103.2
+	github.com/lib/pq v1.10.7
+	github.com/mattn/go-sqlite3 v1.14.16
 	google.golang.org/grpc v1.43.0
 	google.golang.org/protobuf v1.27.1
 )
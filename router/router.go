@@ -1,13 +1,37 @@
 package router
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/gorilla/mux"
 
 	"github.com/plural-labs/stakebot/bot"
 	v1 "github.com/plural-labs/stakebot/router/v1"
 )
 
-func RegisterRoutes(router *mux.Router, bot *bot.AutoStakeBot) {
+const (
+	requestTimeout = 10 * time.Second
+	// untimedPath is not bound by the request timeout
+	untimedPath = "/v1/restake"
+)
+
+func RegisterRoutes(router *mux.Router, bot *bot.AutoStakeBot, adminToken string) {
+	// admin routes stream backups and are thus not bound by the request timeout
+	admin := router.PathPrefix("/v1/admin").Subrouter()
+	v1.RegisterAdminRoutes(admin, bot, adminToken)
+
 	r := router.PathPrefix("/v1").Subrouter()
+	r.Use(func(next http.Handler) http.Handler {
+		timeout := http.TimeoutHandler(next, requestTimeout, "Request timed out")
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			// manual restakes are bound by the restake timeout instead, which is longer
+			if req.URL.Path == untimedPath {
+				next.ServeHTTP(res, req)
+				return
+			}
+			timeout.ServeHTTP(res, req)
+		})
+	})
 	v1.RegisterRoutes(r, bot)
 }
@@ -3,7 +3,6 @@ package router
 import (
 	"context"
 	"net/http"
-	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
@@ -11,15 +10,15 @@ import (
 	"github.com/plural-labs/stakebot/bot"
 )
 
-func Serve(ctx context.Context, listenAddr string, stakebot *bot.AutoStakeBot) error {
+func Serve(ctx context.Context, listenAddr, adminToken string, stakebot *bot.AutoStakeBot) error {
 	r := mux.NewRouter()
-	RegisterRoutes(r, stakebot)
+	RegisterRoutes(r, stakebot, adminToken)
 
+	// writes are bounded per route by RegisterRoutes so that backups can be streamed
 	server := &http.Server{
-		Handler:      r,
-		Addr:         listenAddr,
-		WriteTimeout: 10 * time.Second,
-		ReadTimeout:  10 * time.Second,
+		Handler:     r,
+		Addr:        listenAddr,
+		ReadTimeout: requestTimeout,
 	}
 
 	go func() {
@@ -0,0 +1,178 @@
+package v1
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+
+	"github.com/plural-labs/stakebot/bot"
+	"github.com/plural-labs/stakebot/store"
+)
+
+// RegisterAdminRoutes registers the operator endpoints. Every request must carry the
+// admin token as a bearer token. No routes are registered if the token is empty.
+func RegisterAdminRoutes(router *mux.Router, bot *bot.AutoStakeBot, token string) {
+	if token == "" {
+		return
+	}
+	h := &AdminHandler{bot: bot}
+	router.Use(authorize(token))
+	router.HandleFunc("/backup", h.Backup).Methods("GET")
+	router.HandleFunc("/pause", h.SetPaused(true)).Methods("POST")
+	router.HandleFunc("/resume", h.SetPaused(false)).Methods("POST")
+	router.HandleFunc("/unsuspend", h.Unsuspend).Methods("POST")
+}
+
+// PausedResponse lists the paused chains after pausing or resuming a chain
+type PausedResponse struct {
+	PausedChains []string `json:"paused_chains"`
+}
+
+type AdminHandler struct {
+	bot *bot.AutoStakeBot
+}
+
+// Backup streams a consistent snapshot of the store which can be loaded with
+// `stakebot restore`. Only stores implementing store.Backuper support backups.
+func (h AdminHandler) Backup(res http.ResponseWriter, req *http.Request) {
+	backuper, ok := h.bot.Store.(store.Backuper)
+	if !ok {
+		RespondWithJSON(res, http.StatusNotImplemented, "The configured store does not support backups")
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/octet-stream")
+	res.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=stakebot-%s.backup", time.Now().UTC().Format("20060102T150405Z")))
+	res.WriteHeader(http.StatusOK)
+	// the status has already been sent so a failure can only be logged and the
+	// truncated stream is rejected on restore
+	if err := backuper.Backup(res); err != nil {
+		log.Error().Err(err).Msg("Streaming backup")
+	}
+}
+
+// SetPaused pauses or resumes the record of the address parameter or, without an
+// address, all records of the chain_id parameter. Paused records keep their settings and
+// history but are not restaked on schedule.
+func (h AdminHandler) SetPaused(paused bool) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		chainID := req.URL.Query().Get("chain_id")
+		address := req.URL.Query().Get("address")
+		if address == "" {
+			if chainID == "" {
+				RespondWithJSON(res, http.StatusBadRequest, "No address or chain_id specified")
+				return
+			}
+			if _, err := h.bot.Chains().FindChainById(chainID); err != nil {
+				RespondWithJSON(res, http.StatusBadRequest, err.Error())
+				return
+			}
+			if err := h.bot.PauseChain(chainID, paused); err != nil {
+				log.Error().Err(err).Str("chain", chainID).Msg("Pausing chain")
+				RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+				return
+			}
+			chains, err := h.bot.Store.PausedChains()
+			if err != nil {
+				RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+				return
+			}
+			log.Info().Str("chain", chainID).Bool("paused", paused).Msg("Changed paused state of chain")
+			RespondWithJSON(res, http.StatusOK, PausedResponse{PausedChains: chains})
+			return
+		}
+
+		chain, err := h.bot.Chains().FindChain(chainID, address)
+		if err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, err.Error())
+			return
+		}
+		record, err := h.bot.PauseRecord(chain.Id, address, paused)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				RespondWithJSON(res, http.StatusNotFound, err.Error())
+				return
+			}
+			log.Error().Err(err).Str("address", address).Msg("Pausing record")
+			RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+			return
+		}
+		log.Info().Str("address", address).Str("chain", chain.Id).Bool("paused", paused).Msg("Changed paused state of record")
+		RespondWithJSON(res, http.StatusOK, record)
+	}
+}
+
+// authorize rejects requests without the bearer token
+func authorize(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			provided := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				RespondWithJSON(res, http.StatusUnauthorized, "Invalid admin token")
+				return
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+}
+
+// Unsuspend resumes restaking a record that was suspended after failing repeatedly. The
+// grants of the address are validated again first, as they are the usual cause.
+func (h AdminHandler) Unsuspend(res http.ResponseWriter, req *http.Request) {
+	address := req.URL.Query().Get("address")
+	if address == "" {
+		RespondWithJSON(res, http.StatusBadRequest, "No address specified")
+		return
+	}
+	chain, err := h.bot.Chains().FindChain(req.URL.Query().Get("chain_id"), address)
+	if err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, err.Error())
+		return
+	}
+	record, err := h.bot.Store.GetRecord(chain.Id, address)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			RespondWithJSON(res, http.StatusNotFound, err.Error())
+			return
+		}
+		log.Error().Err(err).Str("address", address).Msg("Getting record")
+		RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !record.Suspended {
+		RespondWithJSON(res, http.StatusOK, record)
+		return
+	}
+
+	conn, err := grpc.Dial(chain.GRPC, grpc.WithInsecure())
+	if err != nil {
+		log.Error().Err(err).Msg("Unsuspending address")
+		RespondWithJSON(res, http.StatusBadGateway, fmt.Sprintf("Unable to connect to gRPC server (%s)", chain.GRPC))
+		return
+	}
+	defer conn.Close()
+	bech32Address, err := h.bot.Bech32Address(chain.Id)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := ValidateAddress(req.Context(), conn, address, bech32Address, record.Type); err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unable to validate address %s, error: %s", address, err.Error()))
+		return
+	}
+
+	record, err = h.bot.Unsuspend(chain.Id, address)
+	if err != nil {
+		log.Error().Err(err).Str("address", address).Msg("Unsuspending record")
+		RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+		return
+	}
+	log.Info().Str("address", address).Str("chain", chain.Id).Msg("Unsuspended record")
+	RespondWithJSON(res, http.StatusOK, record)
+}
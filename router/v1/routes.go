@@ -3,7 +3,9 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
@@ -20,6 +22,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/plural-labs/stakebot/bot"
+	"github.com/plural-labs/stakebot/store"
 	"github.com/plural-labs/stakebot/types"
 )
 
@@ -31,6 +34,28 @@ func RegisterRoutes(router *mux.Router, bot *bot.AutoStakeBot) {
 	router.HandleFunc("/address", h.Address).Methods("GET")
 	router.HandleFunc("/register", h.RegisterAddress).Methods("GET")
 	router.HandleFunc("/restake", h.Restake).Methods("GET")
+	router.HandleFunc("/history", h.History).Methods("GET")
+	router.HandleFunc("/records", h.Records).Methods("GET")
+	router.HandleFunc("/stats", h.Stats).Methods("GET")
+}
+
+const (
+	defaultHistoryLimit = 100
+	defaultRecordsLimit = 100
+)
+
+// RecordsResponse is a single page of records. Next is empty on the last page.
+type RecordsResponse struct {
+	Records []*types.Record `json:"records"`
+	Next    string          `json:"next,omitempty"`
+}
+
+// StatusResponse is a record together with the time it is restaked next. NextRun is
+// omitted while the record is being restaked, suspended or paused.
+type StatusResponse struct {
+	*types.Record
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	ChainPaused bool       `json:"chain_paused,omitempty"`
 }
 
 type Handler struct {
@@ -43,12 +68,27 @@ func (h Handler) Status(res http.ResponseWriter, req *http.Request) {
 		res.WriteHeader(http.StatusOK)
 		return
 	} else {
-		record, err := h.bot.Store.GetRecord(address)
+		chain, err := h.bot.Chains().FindChain(req.URL.Query().Get("chain_id"), address)
 		if err != nil {
 			RespondWithJSON(res, http.StatusOK, err.Error())
-		} else {
-			RespondWithJSON(res, http.StatusOK, record)
+			return
 		}
+		record, err := h.bot.Store.GetRecord(chain.Id, address)
+		if err != nil {
+			RespondWithJSON(res, http.StatusOK, err.Error())
+			return
+		}
+		chainPaused, err := h.bot.ChainPaused(chain.Id)
+		if err != nil {
+			log.Error().Err(err).Msg("Reading paused chains")
+			RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+			return
+		}
+		status := StatusResponse{Record: record, ChainPaused: chainPaused}
+		if next, ok := h.bot.NextRun(chain.Id, address); ok && !record.Suspended && !record.Paused && !chainPaused {
+			status.NextRun = &next
+		}
+		RespondWithJSON(res, http.StatusOK, status)
 	}
 }
 
@@ -80,19 +120,27 @@ func (h Handler) RegisterAddress(res http.ResponseWriter, req *http.Request) {
 	}
 	frequencyStr := req.URL.Query().Get("frequency")
 	toleranceStr := req.URL.Query().Get("tolerance")
+	scheduleStr := req.URL.Query().Get("schedule")
 
-	chain, err := h.bot.Chains().FindChainFromAddress(address)
+	chain, err := h.bot.Chains().FindChain(req.URL.Query().Get("chain_id"), address)
 	if err != nil {
-		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("No chain saved corresponds with the address %s", address))
+		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("No chain saved corresponds with the address %s: %s", address, err.Error()))
+		return
 	}
 	var (
 		frequency int32
 		tolerance int64
 		ok        bool
 	)
-	if frequencyStr == "" {
+	switch {
+	case scheduleStr != "" && frequencyStr != "":
+		RespondWithJSON(res, http.StatusBadRequest, "Only one of frequency and schedule can be specified")
+		return
+	case scheduleStr != "":
+		frequency = int32(types.Frequency_UNKNOWN)
+	case frequencyStr == "":
 		frequency = chain.DefaultFrequency
-	} else {
+	default:
 		frequency, ok = types.Frequency_value[strings.ToUpper(frequencyStr)]
 		if !ok {
 			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unknown interval %s", frequencyStr))
@@ -110,19 +158,84 @@ func (h Handler) RegisterAddress(res http.ResponseWriter, req *http.Request) {
 		tolerance = int64(number)
 	}
 
+	record := &types.Record{
+		ChainId:   chain.Id,
+		Address:   address,
+		Frequency: types.Frequency(frequency),
+		Tolerance: tolerance,
+		Schedule:  scheduleStr,
+	}
+	if minRewardsStr := req.URL.Query().Get("min_rewards"); minRewardsStr != "" {
+		minRewards, err := strconv.ParseInt(minRewardsStr, 10, 64)
+		if err != nil || minRewards < 0 {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Invalid min_rewards %s", minRewardsStr))
+			return
+		}
+		record.MinRewards = &minRewards
+	}
+	if multipleStr := req.URL.Query().Get("min_rewards_fee_multiple"); multipleStr != "" {
+		multiple, err := strconv.ParseFloat(multipleStr, 64)
+		if err != nil || multiple < 0 || math.IsNaN(multiple) || math.IsInf(multiple, 0) {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Invalid min_rewards_fee_multiple %s", multipleStr))
+			return
+		}
+		record.MinRewardsFeeMultiple = &multiple
+	}
+	if allocationStr := req.URL.Query().Get("allocation"); allocationStr != "" {
+		allocation, ok := types.AllocationStrategy_value[strings.ToUpper(allocationStr)]
+		if !ok {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unknown allocation strategy %s", allocationStr))
+			return
+		}
+		record.Allocation = types.AllocationStrategy(allocation)
+	}
+	record.AllocationValidator = req.URL.Query().Get("validator")
+	if weightsStr := req.URL.Query().Get("weights"); weightsStr != "" {
+		record.AllocationWeights, err = parseWeights(weightsStr)
+		if err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Invalid weights: %s", err.Error()))
+			return
+		}
+	}
+	if typeStr := req.URL.Query().Get("type"); typeStr != "" {
+		recordType, ok := types.RecordType_value[strings.ToUpper(typeStr)]
+		if !ok {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unknown record type %s", typeStr))
+			return
+		}
+		record.Type = types.RecordType(recordType)
+	}
+	if policyStr := req.URL.Query().Get("unhealthy_policy"); policyStr != "" {
+		policy, ok := types.UnhealthyPolicy_value[strings.ToUpper(policyStr)]
+		if !ok {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unknown unhealthy validator policy %s", policyStr))
+			return
+		}
+		record.UnhealthyPolicy = types.UnhealthyPolicy(policy)
+	}
+	if _, err := bot.NewAllocator(chain, record); err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Invalid allocation: %s", err.Error()))
+		return
+	}
+	if _, err := chain.ValidateSchedule(record.ScheduleSpec()); err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Invalid schedule: %s", err.Error()))
+		return
+	}
+
 	conn, err := grpc.Dial(chain.GRPC, grpc.WithInsecure())
 	if err != nil {
 		log.Error().Err(err).Msg("Registering address")
 		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unable to connect to gRPC server (%s)", chain.GRPC))
 		return
 	}
+	defer conn.Close()
 
 	bech32Address, err := h.bot.Bech32Address(chain.Id)
 	if err != nil {
 		panic(err)
 	}
 
-	valid, err := ValidateAddress(req.Context(), conn, address, bech32Address)
+	valid, err := ValidateAddress(req.Context(), conn, address, bech32Address, record.Type)
 	if err != nil {
 		log.Error().Err(err).Msg("Registering address")
 	}
@@ -131,17 +244,53 @@ func (h Handler) RegisterAddress(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	record := &types.Record{
-		Address:   address,
-		Frequency: types.Frequency(frequency),
-		Tolerance: tolerance,
+	if record.Type == types.RecordType_VALIDATOR_OPERATOR {
+		operatorAddress, err := chain.OperatorAddress(address)
+		if err == nil {
+			_, err = staking.NewQueryClient(conn).Validator(req.Context(), &staking.QueryValidatorRequest{ValidatorAddr: operatorAddress})
+		}
+		if err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Address %s does not operate a validator: %s", address, err.Error()))
+			return
+		}
 	}
-	err = h.bot.Store.SetRecord(record)
+
+	// the targets of the allocation must exist so that typos don't fail every restake
+	var targets []string
+	switch record.Allocation {
+	case types.AllocationStrategy_SINGLE_VALIDATOR:
+		targets = append(targets, record.AllocationValidator)
+	case types.AllocationStrategy_TARGET_WEIGHTS:
+		for _, weight := range record.AllocationWeights {
+			targets = append(targets, weight.ValidatorAddress)
+		}
+	}
+	for _, target := range targets {
+		if _, err := staking.NewQueryClient(conn).Validator(req.Context(), &staking.QueryValidatorRequest{ValidatorAddr: target}); err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unknown validator %s: %s", target, err.Error()))
+			return
+		}
+	}
+
+	if record.Frequency == types.Frequency_AUTO {
+		// on failure the record starts out daily until the bot computes its interval
+		params, err := bot.QueryCompoundingParams(req.Context(), conn, chain, address)
+		if err != nil {
+			log.Warn().Err(err).Str("address", address).Msg("Computing restake interval")
+		} else {
+			record.SetAutoInterval(bot.OptimalInterval(params, chain.MinInterval.Duration), time.Now())
+		}
+	}
+
+	record, err = h.bot.Register(record)
 	if err != nil {
 		log.Error().Err(err).Msg("Saving new record")
 		// TODO: should return a 500 error
 		return
 	}
+	if err := h.bot.Schedule(record); err != nil {
+		log.Error().Err(err).Msg("Scheduling new record")
+	}
 
 	// Success!
 	RespondWithJSON(res, http.StatusOK, record)
@@ -171,53 +320,217 @@ func (h Handler) Restake(res http.ResponseWriter, req *http.Request) {
 		RespondWithJSON(res, http.StatusBadRequest, "No address specified")
 		return
 	}
-	var (
-		tolerance int64
-		err       error
-	)
+	chain, err := h.bot.Chains().FindChain(req.URL.Query().Get("chain_id"), address)
+	if err != nil {
+		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("No chain saved corresponds with the address %s: %s", address, err.Error()))
+		return
+	}
 
-	record, err := h.bot.Store.GetRecord(address)
+	record, err := h.bot.Store.GetRecord(chain.Id, address)
 	if err != nil {
 		log.Error().Err(err).Str("address", address).Msg("Getting record")
 		RespondWithJSON(res, http.StatusOK, err.Error())
 		return
 	}
 
-	toleranceStr := req.URL.Query().Get("tolerance")
-	if toleranceStr == "" {
-		tolerance = record.Tolerance
-	} else {
-		tolerance, err = strconv.ParseInt(toleranceStr, 10, 64)
+	opts, err := h.bot.RestakeOptions(chain, record)
+	if err != nil {
+		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("Invalid allocation: %s", err.Error()))
+		return
+	}
+	if toleranceStr := req.URL.Query().Get("tolerance"); toleranceStr != "" {
+		opts.Tolerance, err = strconv.ParseInt(toleranceStr, 10, 64)
 		if err != nil {
 			RespondWithJSON(res, http.StatusOK, err.Error())
 			return
 		}
 	}
 
-	chain, err := h.bot.Chains().FindChainFromAddress(address)
+	// the restake is not bound to the request so that it is saved even if the client
+	// gives up waiting
+	ctx, cancel := h.bot.RestakeContext()
+	defer cancel()
+	event, err := h.bot.Restake(ctx, chain.Id, address, opts)
+	updateErr := h.bot.SaveResult(event, err)
+	if updateErr != nil {
+		log.Error().Err(updateErr).Str("address", address).Msg("Saving record")
+	}
 	if err != nil {
-		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("No chain saved corresponds with the address %s", address))
+		log.Error().Err(err).Str("address", address).Msg("Restaking")
+		RespondWithJSON(res, http.StatusOK, err.Error())
+		return
 	}
 
-	value, err := h.bot.Restake(context.Background(), address, tolerance, sdk.NewInt64Coin(chain.NativeDenom, chain.RestakeFee))
-	record.LastUpdatedUnixTime = time.Now().Unix()
+	if event.Outcome == types.Outcome_SKIPPED {
+		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("Skipped restaking %d tokens, below the threshold of %d\n", event.TotalRewards+event.Commission, chain.RewardThreshold(record)))
+		return
+	}
+	if event.Outcome == types.Outcome_SIMULATED {
+		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("Dry run: would have restaked %d tokens using %d gas\n", event.TotalRewards+event.Commission, event.GasUsed))
+		return
+	}
+	if event.Outcome != types.Outcome_SUCCESS {
+		RespondWithJSON(res, http.StatusOK, "Successfully restaked 0 tokens\n")
+		return
+	}
+	if event.Commission > 0 {
+		RespondWithJSON(res, http.StatusOK, fmt.Sprintf("Successfully restaked %d tokens, %d of rewards and %d of commission\n", event.TotalRewards+event.Commission, event.TotalRewards, event.Commission))
+		return
+	}
+	RespondWithJSON(res, http.StatusOK, fmt.Sprintf("Successfully restaked %d tokens\n", event.TotalRewards))
+}
+
+// History returns the restake events of an address in chronological order. The optional
+// from (inclusive) and to (exclusive) parameters accept either unix seconds or RFC3339
+// timestamps and limit caps the amount of events returned.
+func (h Handler) History(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	address := query.Get("address")
+	if address == "" {
+		RespondWithJSON(res, http.StatusBadRequest, "No address specified")
+		return
+	}
+	chain, err := h.bot.Chains().FindChain(query.Get("chain_id"), address)
 	if err != nil {
-		log.Error().Err(err).Str("address", address).Msg("Restaking")
-		record.ErrorLogs = err.Error()
-		if err := h.bot.Store.SetRecord(record); err != nil {
-			log.Error().Err(err).Str("address", address).Msg("Saving record")
+		RespondWithJSON(res, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	from, err := parseTime(query.Get("from"))
+	if err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Failed to parse from: %s", err.Error()))
+		return
+	}
+	to, err := parseTime(query.Get("to"))
+	if err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Failed to parse to: %s", err.Error()))
+		return
+	}
+	limit := defaultHistoryLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Failed to parse limit: %s", err.Error()))
+			return
 		}
+	}
 
-		RespondWithJSON(res, http.StatusOK, err.Error())
+	events, err := h.bot.Store.GetHistory(chain.Id, address, from, to, limit)
+	if err != nil {
+		log.Error().Err(err).Str("address", address).Msg("Getting history")
+		RespondWithJSON(res, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	record.TotalAutostakedRewards += value
-	if err := h.bot.Store.SetRecord(record); err != nil {
-		log.Error().Err(err).Str("address", address).Msg("Saving record")
+	RespondWithJSON(res, http.StatusOK, events)
+}
+
+// Records lists registered records ordered by chain and address. Records can be
+// filtered by chain_id, frequency, has_error and the updated_after (inclusive) and
+// updated_before (exclusive) times. Pages of limit records are continued by passing the
+// returned next value as cursor.
+func (h Handler) Records(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	filter := store.Filter{
+		ChainID: query.Get("chain_id"),
+		Cursor:  query.Get("cursor"),
+		Limit:   defaultRecordsLimit,
+	}
+	if filter.ChainID != "" {
+		if _, err := h.bot.Chains().FindChainById(filter.ChainID); err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if frequency := query.Get("frequency"); frequency != "" {
+		value, ok := types.Frequency_value[strings.ToUpper(frequency)]
+		if !ok {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Unknown frequency %s", frequency))
+			return
+		}
+		filter.Frequency = types.Frequency(value)
+	}
+	if hasError := query.Get("has_error"); hasError != "" {
+		value, err := strconv.ParseBool(hasError)
+		if err != nil {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Failed to parse has_error: %s", err.Error()))
+			return
+		}
+		filter.HasError = &value
+	}
+
+	var err error
+	filter.UpdatedAfter, err = parseTime(query.Get("updated_after"))
+	if err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Failed to parse updated_after: %s", err.Error()))
+		return
+	}
+	filter.UpdatedBefore, err = parseTime(query.Get("updated_before"))
+	if err != nil {
+		RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Failed to parse updated_before: %s", err.Error()))
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		filter.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || filter.Limit <= 0 {
+			RespondWithJSON(res, http.StatusBadRequest, fmt.Sprintf("Invalid limit %s", limitStr))
+			return
+		}
+	}
+
+	records, next, err := h.bot.Store.List(filter)
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidCursor) {
+			RespondWithJSON(res, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Error().Err(err).Msg("Listing records")
+		RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+		return
 	}
 
-	RespondWithJSON(res, http.StatusOK, fmt.Sprintf("Successfully restaked %d tokens\n", value))
+	RespondWithJSON(res, http.StatusOK, RecordsResponse{Records: records, Next: next})
+}
+
+// Stats reports the amount of records and events and, for the badger store, the size of
+// the database on disk and when its value log was last garbage collected.
+func (h Handler) Stats(res http.ResponseWriter, req *http.Request) {
+	stats, err := h.bot.Store.Stats()
+	if err != nil {
+		log.Error().Err(err).Msg("Getting store stats")
+		RespondWithJSON(res, http.StatusInternalServerError, err.Error())
+		return
+	}
+	RespondWithJSON(res, http.StatusOK, stats)
+}
+
+// parseTime parses either unix seconds or an RFC3339 timestamp. An empty string
+// returns the zero time.
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339Nano, value)
+}
+
+// parseWeights parses a comma separated list of validator:weight pairs
+func parseWeights(value string) ([]*types.ValidatorWeight, error) {
+	var weights []*types.ValidatorWeight
+	for _, pair := range strings.Split(value, ",") {
+		validator, weightStr := pair, ""
+		if idx := strings.LastIndex(pair, ":"); idx >= 0 {
+			validator, weightStr = pair[:idx], pair[idx+1:]
+		}
+		weight, err := strconv.ParseFloat(weightStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected validator:weight, got %q", pair)
+		}
+		weights = append(weights, &types.ValidatorWeight{ValidatorAddress: strings.TrimSpace(validator), Weight: weight})
+	}
+	return weights, nil
 }
 
 // RespondWithJSON provides an auxiliary function to return an HTTP response
@@ -234,8 +547,9 @@ func RespondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 }
 
 // ValidateAddress checks whether a specified address is valid for autostaking. The address must
-// have granted authorization of the required messages as well as feegrant
-func ValidateAddress(ctx context.Context, conn *grpc.ClientConn, address, authority string) (bool, error) {
+// have granted authorization of the required messages as well as feegrant. Validator operators
+// must also authorize withdrawing the commission of their validator.
+func ValidateAddress(ctx context.Context, conn *grpc.ClientConn, address, authority string, recordType types.RecordType) (bool, error) {
 	feegrantClient := feegrant.NewQueryClient(conn)
 	resp, err := feegrantClient.Allowance(ctx, &feegrant.QueryAllowanceRequest{
 		Granter: address,
@@ -289,5 +603,19 @@ func ValidateAddress(ctx context.Context, conn *grpc.ClientConn, address, author
 		return false, fmt.Errorf("grants %s must authorize the stakebot (%s) to MsgWithdrawDelegatorReward", address, authority)
 	}
 
+	if recordType == types.RecordType_VALIDATOR_OPERATOR {
+		grantsResp, err = authzClient.Grants(ctx, &authz.QueryGrantsRequest{
+			Granter:    address,
+			Grantee:    authority,
+			MsgTypeUrl: sdk.MsgTypeURL(&distribution.MsgWithdrawValidatorCommission{}),
+		})
+		if err != nil {
+			return false, fmt.Errorf("authorization MsgWithdrawValidatorCommission query: %w", err)
+		}
+		if len(grantsResp.Grants) == 0 {
+			return false, fmt.Errorf("address %s must authorize the stakebot (%s) to MsgWithdrawValidatorCommission", address, authority)
+		}
+	}
+
 	return true, nil
 }
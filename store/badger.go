@@ -0,0 +1,623 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sync"
+	"time"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/google/orderedcode"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+const (
+	defaultStoreName = "store.db"
+
+	addressPrefix = byte(0x00)
+	historyPrefix = byte(0x01)
+	// indexPrefix maps a chain id and address to the frequency the record is stored under
+	indexPrefix    = byte(0x02)
+	metadataPrefix = byte(0x03)
+)
+
+var _ Store = &BadgerStore{}
+
+// BadgerStore is the default Store, an embedded badger database kept in the stakebot's
+// home directory.
+type BadgerStore struct {
+	db *badger.DB
+	gc *gcLoop
+}
+
+// gcLoop garbage collects the value log in the background until stopped
+type gcLoop struct {
+	interval     time.Duration
+	discardRatio float64
+	stop         chan struct{}
+	stopOnce     sync.Once
+	done         chan struct{}
+
+	mtx      sync.Mutex
+	lastRun  time.Time
+	rewrites int
+}
+
+// NewBadger opens the database in dir. If the config sets a GC interval the value log is
+// garbage collected in the background until the store is closed.
+func NewBadger(dir string, cfg types.StoreConfig) (*BadgerStore, error) {
+	if cfg.GCInterval.Duration > 0 && (cfg.GCDiscardRatio <= 0 || cfg.GCDiscardRatio >= 1) {
+		return nil, fmt.Errorf("gc discard ratio must be between 0 and 1, got %v", cfg.GCDiscardRatio)
+	}
+	path := filepath.Join(dir, defaultStoreName)
+	db, err := badger.Open(badger.DefaultOptions(path))
+	if err != nil {
+		return nil, err
+	}
+	s := &BadgerStore{
+		db: db,
+	}
+	// new databases start out at the latest version
+	empty, err := s.isEmpty()
+	if err == nil && empty {
+		err = s.db.Update(func(txn *badger.Txn) error {
+			return setVersion(txn, SchemaVersion)
+		})
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	if cfg.GCInterval.Duration > 0 {
+		s.gc = &gcLoop{
+			interval:     cfg.GCInterval.Duration,
+			discardRatio: cfg.GCDiscardRatio,
+			stop:         make(chan struct{}),
+			done:         make(chan struct{}),
+		}
+		go s.runGC()
+	}
+	return s, nil
+}
+
+func (s BadgerStore) runGC() {
+	defer close(s.gc.done)
+	ticker := time.NewTicker(s.gc.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.gc.stop:
+			return
+		case <-ticker.C:
+			rewrites, err := s.collectGarbage()
+			if err != nil {
+				log.Error().Err(err).Msg("Garbage collecting value log")
+				continue
+			}
+			log.Debug().Int("rewrites", rewrites).Msg("Garbage collected value log")
+		}
+	}
+}
+
+// collectGarbage rewrites value log files until there are none left worth rewriting
+// or the loop is stopped
+func (s BadgerStore) collectGarbage() (int, error) {
+	rewrites := 0
+	defer func() {
+		s.gc.mtx.Lock()
+		s.gc.lastRun = time.Now()
+		s.gc.rewrites += rewrites
+		s.gc.mtx.Unlock()
+	}()
+	for {
+		select {
+		case <-s.gc.stop:
+			return rewrites, nil
+		default:
+		}
+		err := s.db.RunValueLogGC(s.gc.discardRatio)
+		switch {
+		case errors.Is(err, badger.ErrNoRewrite), errors.Is(err, badger.ErrRejected):
+			return rewrites, nil
+		case err != nil:
+			return rewrites, err
+		}
+		rewrites++
+	}
+}
+
+// Stats reports the size of the LSM tree and value log on disk
+func (s BadgerStore) Stats() (Stats, error) {
+	stats := Stats{Backend: types.StoreBackendBadger}
+	stats.LSMSize, stats.ValueLogSize = s.db.Size()
+	if s.gc != nil {
+		s.gc.mtx.Lock()
+		stats.LastGC, stats.GCRewrites = s.gc.lastRun, s.gc.rewrites
+		s.gc.mtx.Unlock()
+	}
+	var err error
+	stats.Records, err = s.Len()
+	if err != nil {
+		return stats, err
+	}
+	err = s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte{historyPrefix}
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			stats.Events++
+		}
+		return nil
+	})
+	return stats, err
+}
+
+func (s BadgerStore) SetChainPaused(chainID string, paused bool) error {
+	key := pausedChainKey(chainID)
+	return s.db.Update(func(txn *badger.Txn) error {
+		if paused {
+			return txn.Set(key, nil)
+		}
+		return txn.Delete(key)
+	})
+}
+
+func (s BadgerStore) PausedChains() ([]string, error) {
+	chains := make([]string, 0)
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = pausedChainPrefix
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var chainID string
+			if _, err := orderedcode.Parse(string(it.Item().Key()[len(pausedChainPrefix):]), &chainID); err != nil {
+				return err
+			}
+			chains = append(chains, chainID)
+		}
+		return nil
+	})
+	return chains, err
+}
+
+// Version returns the schema version of the database. Databases written before versioning
+// was introduced are version 0.
+func (s BadgerStore) Version() (int, error) {
+	var version int
+	err := s.db.View(func(txn *badger.Txn) (err error) {
+		version, err = getVersion(txn)
+		return err
+	})
+	return version, err
+}
+
+// Migrate runs all migrations from the current version up to SchemaVersion. Each migration
+// is committed in its own transaction together with the new version. A dry run runs all
+// migrations in a single transaction that is then discarded.
+func (s BadgerStore) Migrate(chains types.ChainRegistry, dryRun bool) ([]Migration, error) {
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("store version %d is newer than the supported version %d", version, SchemaVersion)
+	}
+
+	applied := make([]Migration, 0)
+	txn := s.db.NewTransaction(true)
+	defer func() { txn.Discard() }()
+	for _, m := range badgerMigrations[version:] {
+		if err := m.migrate(txn, chains); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		if err := setVersion(txn, m.Version); err != nil {
+			return applied, err
+		}
+		if !dryRun {
+			if err := txn.Commit(); err != nil {
+				return applied, fmt.Errorf("committing migration %d: %w", m.Version, err)
+			}
+			txn = s.db.NewTransaction(true)
+		}
+		applied = append(applied, m.Migration)
+	}
+	return applied, nil
+}
+
+// isEmpty returns true if the database has no keys at all
+func (s BadgerStore) isEmpty() (bool, error) {
+	empty := true
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		it.Rewind()
+		empty = !it.Valid()
+		return nil
+	})
+	return empty, err
+}
+
+func (s BadgerStore) SetRecord(record *types.Record) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return setRecord(txn, record)
+	})
+}
+
+func (s BadgerStore) GetRecord(chainID, address string) (*types.Record, error) {
+	var record *types.Record
+	err := s.db.View(func(txn *badger.Txn) (err error) {
+		record, err = getRecord(txn, chainID, address)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s BadgerStore) DeleteRecord(chainID, address string) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return deleteRecord(txn, chainID, address)
+	})
+}
+
+// Update reads, modifies and writes back the record of an address within a single
+// transaction. Transactions that conflict with a concurrent write are retried, thus
+// fn may be called more than once.
+func (s BadgerStore) Update(chainID, address string, fn func(*types.Record) error) error {
+	for {
+		err := s.db.Update(func(txn *badger.Txn) error {
+			record, err := getRecord(txn, chainID, address)
+			if err != nil {
+				return err
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+			record.ChainId, record.Address = chainID, address
+			return setRecord(txn, record)
+		})
+		if err != badger.ErrConflict {
+			return err
+		}
+	}
+}
+
+func (s BadgerStore) GetRecordsByFrequency(frequency int32) ([]*types.Record, error) {
+	records := make([]*types.Record, 0)
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		prefix, err := orderedcode.Append([]byte{addressPrefix}, int64(frequency))
+		if err != nil {
+			panic(err)
+		}
+		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+			record := new(types.Record)
+			item := it.Item()
+			err := item.Value(func(v []byte) error {
+				if err := proto.Unmarshal(v, record); err != nil {
+					return err
+				}
+				records = append(records, record)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+// List walks the address index, which is ordered by chain id and address, and reads
+// each record from its frequency.
+func (s BadgerStore) List(filter Filter) ([]*types.Record, string, error) {
+	prefix := []byte{indexPrefix}
+	if filter.ChainID != "" {
+		prefix = mustAppend(indexPrefix, filter.ChainID)
+	}
+	start := prefix
+	if filter.Cursor != "" {
+		chainID, address, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		// the byte appended skips the cursor itself
+		start = append(indexKey(chainID, address), 0x00)
+	}
+
+	page := newPage(filter.Limit)
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(start); it.Valid(); it.Next() {
+			var chainID, address string
+			if _, err := orderedcode.Parse(string(it.Item().Key()[1:]), &chainID, &address); err != nil {
+				return err
+			}
+			record, err := getRecord(txn, chainID, address)
+			if err != nil {
+				return err
+			}
+			if !filter.Match(record) {
+				continue
+			}
+			if !page.add(record) {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return page.records, page.next, nil
+}
+
+// AppendEvent adds an event to the history log of the event's address. Events are keyed
+// by chain, address and timestamp and are never modified once written.
+func (s BadgerStore) AppendEvent(event *types.Event) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		key := historyKey(event.ChainId, event.Address, event.UnixNanoTime)
+		_, err := txn.Get(key)
+		if err == nil {
+			return fmt.Errorf("%w: %s at %d", ErrEventExists, event.Address, event.UnixNanoTime)
+		}
+		if !errors.Is(err, badger.ErrKeyNotFound) {
+			return err
+		}
+		bz, err := proto.Marshal(event)
+		if err != nil {
+			return err
+		}
+		return txn.Set(key, bz)
+	})
+}
+
+// GetHistory returns up to limit events of an address, in chronological order, that
+// occurred at or after from and before to. A zero to means no upper bound and a limit
+// of zero or less means no limit.
+func (s BadgerStore) GetHistory(chainID, address string, from, to time.Time, limit int) ([]*types.Event, error) {
+	events := make([]*types.Event, 0)
+	start, end := historyKey(chainID, address, 0), historyKey(chainID, address, math.MaxInt64)
+	if !from.IsZero() {
+		start = historyKey(chainID, address, from.UnixNano())
+	}
+	if !to.IsZero() {
+		end = historyKey(chainID, address, to.UnixNano())
+	}
+	err := s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Seek(start); it.Valid(); it.Next() {
+			item := it.Item()
+			if bytes.Compare(item.Key(), end) >= 0 {
+				break
+			}
+			if limit > 0 && len(events) >= limit {
+				break
+			}
+			event := new(types.Event)
+			err := item.Value(func(v []byte) error {
+				return proto.Unmarshal(v, event)
+			})
+			if err != nil {
+				return err
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (s BadgerStore) WalkHistory(fn func(*types.Event) error) error {
+	return s.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte{historyPrefix}
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			event := new(types.Event)
+			err := it.Item().Value(func(v []byte) error {
+				return proto.Unmarshal(v, event)
+			})
+			if err != nil {
+				return err
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close waits for a running garbage collection to stop before closing the database
+func (s BadgerStore) Close() error {
+	if s.gc != nil {
+		s.gc.stopOnce.Do(func() { close(s.gc.stop) })
+		<-s.gc.done
+	}
+	return s.db.Close()
+}
+
+func (s BadgerStore) Len() (int, error) {
+	recordCount := 0
+	err := s.db.Update(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte{addressPrefix}
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			recordCount++
+		}
+		return nil
+	})
+	return recordCount, err
+}
+
+// getRecord looks up the frequency of an address in the index and reads the record
+func getRecord(txn *badger.Txn, chainID, address string) (*types.Record, error) {
+	frequency, err := getFrequency(txn, chainID, address)
+	if err != nil {
+		return nil, err
+	}
+	item, err := txn.Get(key(frequency, chainID, address))
+	if err == badger.ErrKeyNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := new(types.Record)
+	err = item.Value(func(val []byte) error {
+		return proto.Unmarshal(val, record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// setRecord writes the record and its index entry, removing the record from the
+// previous frequency if it has changed
+func setRecord(txn *badger.Txn, record *types.Record) error {
+	frequency, err := getFrequency(txn, record.ChainId, record.Address)
+	switch {
+	case err == ErrNotFound:
+	case err != nil:
+		return err
+	case frequency != int32(record.Frequency):
+		if err := txn.Delete(key(frequency, record.ChainId, record.Address)); err != nil {
+			return err
+		}
+	}
+
+	bz, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := txn.Set(key(int32(record.Frequency), record.ChainId, record.Address), bz); err != nil {
+		return err
+	}
+	return txn.Set(indexKey(record.ChainId, record.Address), indexValue(int32(record.Frequency)))
+}
+
+func deleteRecord(txn *badger.Txn, chainID, address string) error {
+	frequency, err := getFrequency(txn, chainID, address)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := txn.Delete(key(frequency, chainID, address)); err != nil {
+		return err
+	}
+	return txn.Delete(indexKey(chainID, address))
+}
+
+func getFrequency(txn *badger.Txn, chainID, address string) (int32, error) {
+	item, err := txn.Get(indexKey(chainID, address))
+	if err == badger.ErrKeyNotFound {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	var frequency int64
+	err = item.Value(func(val []byte) error {
+		_, err := orderedcode.Parse(string(val), &frequency)
+		return err
+	})
+	return int32(frequency), err
+}
+
+func key(frequency int32, chainID, address string) []byte {
+	key, err := orderedcode.Append([]byte{addressPrefix}, int64(frequency), chainID, address)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func historyKey(chainID, address string, unixNanoTime int64) []byte {
+	key, err := orderedcode.Append([]byte{historyPrefix}, chainID, address, unixNanoTime)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func indexKey(chainID, address string) []byte {
+	key, err := orderedcode.Append([]byte{indexPrefix}, chainID, address)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func indexValue(frequency int32) []byte {
+	value, err := orderedcode.Append(nil, int64(frequency))
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+var versionKey = []byte{metadataPrefix, 'v'}
+
+// pausedChainPrefix is followed by the id of every paused chain
+var pausedChainPrefix = []byte{metadataPrefix, 'p'}
+
+func pausedChainKey(chainID string) []byte {
+	key, err := orderedcode.Append(append([]byte(nil), pausedChainPrefix...), chainID)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func getVersion(txn *badger.Txn) (int, error) {
+	item, err := txn.Get(versionKey)
+	if err == badger.ErrKeyNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	err = item.Value(func(val []byte) error {
+		_, err := orderedcode.Parse(string(val), &version)
+		return err
+	})
+	return int(version), err
+}
+
+func setVersion(txn *badger.Txn, version int) error {
+	value, err := orderedcode.Append(nil, int64(version))
+	if err != nil {
+		return err
+	}
+	return txn.Set(versionKey, value)
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+const (
+	// FormatJSON writes one JSON object per line with either a record, an event or a
+	// paused chain
+	FormatJSON = "json"
+	// FormatProto writes varint length delimited types.Entry messages
+	FormatProto = "proto"
+)
+
+// maxEntrySize guards against reading corrupted length prefixes
+const maxEntrySize = 16 << 20
+
+// Backuper is implemented by stores that can stream a consistent snapshot of
+// themselves while in use. The snapshot can be loaded with Restore.
+type Backuper interface {
+	Backup(w io.Writer) error
+}
+
+// Backup streams a full badger backup of all versions at the time of the call.
+func (s BadgerStore) Backup(w io.Writer) error {
+	_, err := s.db.Backup(w, 0)
+	return err
+}
+
+// jsonEntry is a single line of a json export. Exactly one of the fields is set.
+type jsonEntry struct {
+	Record      *types.Record `json:"record,omitempty"`
+	Event       *types.Event  `json:"event,omitempty"`
+	PausedChain string        `json:"paused_chain,omitempty"`
+}
+
+// Counts are the amount of records, events and paused chains exported, imported or
+// restored
+type Counts struct {
+	Records      int
+	Events       int
+	PausedChains int
+}
+
+// Export writes all records and paused chains followed by all history of the store to w
+// in the given format.
+func Export(s Store, w io.Writer, format string) (Counts, error) {
+	var (
+		counts Counts
+		write  func(entry jsonEntry) error
+	)
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(bw)
+		write = func(entry jsonEntry) error { return enc.Encode(entry) }
+	case FormatProto:
+		write = func(entry jsonEntry) error {
+			msg := &types.Entry{}
+			switch {
+			case entry.Record != nil:
+				msg.Entry = &types.Entry_Record{Record: entry.Record}
+			case entry.Event != nil:
+				msg.Entry = &types.Entry_Event{Event: entry.Event}
+			default:
+				msg.Entry = &types.Entry_PausedChain{PausedChain: entry.PausedChain}
+			}
+			bz, err := proto.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			prefix := make([]byte, binary.MaxVarintLen64)
+			if _, err := bw.Write(prefix[:binary.PutUvarint(prefix, uint64(len(bz)))]); err != nil {
+				return err
+			}
+			_, err = bw.Write(bz)
+			return err
+		}
+	default:
+		return counts, fmt.Errorf("unknown export format %q, expected %s or %s", format, FormatJSON, FormatProto)
+	}
+
+	records, _, err := s.List(Filter{})
+	if err != nil {
+		return counts, err
+	}
+	for _, record := range records {
+		if err := write(jsonEntry{Record: record}); err != nil {
+			return counts, err
+		}
+		counts.Records++
+	}
+	pausedChains, err := s.PausedChains()
+	if err != nil {
+		return counts, err
+	}
+	for _, chainID := range pausedChains {
+		if err := write(jsonEntry{PausedChain: chainID}); err != nil {
+			return counts, err
+		}
+		counts.PausedChains++
+	}
+	err = s.WalkHistory(func(event *types.Event) error {
+		if err := write(jsonEntry{Event: event}); err != nil {
+			return err
+		}
+		counts.Events++
+		return nil
+	})
+	if err != nil {
+		return counts, err
+	}
+	return counts, bw.Flush()
+}
+
+// Import reads an export in the given format and writes it to the store. All entries are
+// read and validated against the chain registry before anything is written, thus an
+// invalid export leaves the store untouched. Existing records are overwritten, but as
+// the history is never modified importing an event that already exists fails.
+func Import(s Store, r io.Reader, format string, chains types.ChainRegistry) (Counts, error) {
+	var (
+		records      []*types.Record
+		events       []*types.Event
+		pausedChains []string
+	)
+	br := bufio.NewReader(r)
+	switch format {
+	case FormatJSON:
+		dec := json.NewDecoder(br)
+		for line := 1; ; line++ {
+			var entry jsonEntry
+			err := dec.Decode(&entry)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return Counts{}, fmt.Errorf("entry %d: %w", line, err)
+			}
+			switch {
+			case entry.Record != nil && entry.Event == nil && entry.PausedChain == "":
+				records = append(records, entry.Record)
+			case entry.Event != nil && entry.Record == nil && entry.PausedChain == "":
+				events = append(events, entry.Event)
+			case entry.PausedChain != "" && entry.Record == nil && entry.Event == nil:
+				pausedChains = append(pausedChains, entry.PausedChain)
+			default:
+				return Counts{}, fmt.Errorf("entry %d: expected either a record, an event or a paused chain", line)
+			}
+		}
+	case FormatProto:
+		for n := 1; ; n++ {
+			size, err := binary.ReadUvarint(br)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return Counts{}, fmt.Errorf("entry %d: %w", n, err)
+			}
+			if size > maxEntrySize {
+				return Counts{}, fmt.Errorf("entry %d: size %d exceeds the maximum of %d bytes", n, size, maxEntrySize)
+			}
+			bz := make([]byte, size)
+			if _, err := io.ReadFull(br, bz); err != nil {
+				return Counts{}, fmt.Errorf("entry %d: %w", n, err)
+			}
+			entry := new(types.Entry)
+			if err := proto.Unmarshal(bz, entry); err != nil {
+				return Counts{}, fmt.Errorf("entry %d: %w", n, err)
+			}
+			switch e := entry.Entry.(type) {
+			case *types.Entry_Record:
+				records = append(records, e.Record)
+			case *types.Entry_Event:
+				events = append(events, e.Event)
+			case *types.Entry_PausedChain:
+				pausedChains = append(pausedChains, e.PausedChain)
+			default:
+				return Counts{}, fmt.Errorf("entry %d: expected either a record, an event or a paused chain", n)
+			}
+		}
+	default:
+		return Counts{}, fmt.Errorf("unknown import format %q, expected %s or %s", format, FormatJSON, FormatProto)
+	}
+
+	return load(s, records, events, pausedChains, chains)
+}
+
+// Restore loads a backup written by Backuper into an in memory database, checks its
+// schema version and copies all records, paused chains and history into the store after
+// validating them against the chain registry.
+func Restore(s Store, r io.Reader, chains types.ChainRegistry) (Counts, error) {
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		return Counts{}, err
+	}
+	backup := BadgerStore{db: db}
+	defer backup.Close()
+
+	if err := loadBackup(db, r); err != nil {
+		return Counts{}, fmt.Errorf("loading backup: %w", err)
+	}
+	if err := CheckVersion(backup); err != nil {
+		return Counts{}, fmt.Errorf("backup: %w", err)
+	}
+
+	records, _, err := backup.List(Filter{})
+	if err != nil {
+		return Counts{}, err
+	}
+	pausedChains, err := backup.PausedChains()
+	if err != nil {
+		return Counts{}, err
+	}
+	events := make([]*types.Event, 0)
+	err = backup.WalkHistory(func(event *types.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return Counts{}, err
+	}
+
+	return load(s, records, events, pausedChains, chains)
+}
+
+// loadBackup loads a badger backup. Badger trusts the length prefixes of the backup and
+// panics on corrupted input, which is turned into an error instead.
+func loadBackup(db *badger.DB, r io.Reader) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("corrupted backup: %v", p)
+		}
+	}()
+	return db.Load(r, 256)
+}
+
+// load validates all records, events and paused chains before writing any of them to
+// the store
+func load(s Store, records []*types.Record, events []*types.Event, pausedChains []string, chains types.ChainRegistry) (Counts, error) {
+	var counts Counts
+	for _, record := range records {
+		if err := validate(chains, record.ChainId, record.Address); err != nil {
+			return counts, fmt.Errorf("record %s: %w", record.Address, err)
+		}
+		if _, ok := types.Frequency_name[int32(record.Frequency)]; !ok {
+			return counts, fmt.Errorf("record %s: invalid frequency %d", record.Address, record.Frequency)
+		}
+		if _, err := types.ParseSchedule(record.ScheduleSpec()); err != nil {
+			return counts, fmt.Errorf("record %s: %w", record.Address, err)
+		}
+	}
+	for _, event := range events {
+		if err := validate(chains, event.ChainId, event.Address); err != nil {
+			return counts, fmt.Errorf("event %s at %d: %w", event.Address, event.UnixNanoTime, err)
+		}
+	}
+	for _, chainID := range pausedChains {
+		if _, err := chains.FindChainById(chainID); err != nil {
+			return counts, fmt.Errorf("paused chain: %w", err)
+		}
+	}
+
+	for _, record := range records {
+		if err := s.SetRecord(record); err != nil {
+			return counts, err
+		}
+		counts.Records++
+	}
+	for _, chainID := range pausedChains {
+		if err := s.SetChainPaused(chainID, true); err != nil {
+			return counts, err
+		}
+		counts.PausedChains++
+	}
+	for _, event := range events {
+		if err := s.AppendEvent(event); err != nil {
+			return counts, err
+		}
+		counts.Events++
+	}
+	return counts, nil
+}
+
+// validate checks that the chain is configured and that the address belongs to it
+func validate(chains types.ChainRegistry, chainID, address string) error {
+	if chainID == "" {
+		return errors.New("missing chain id")
+	}
+	_, err := chains.FindChain(chainID, address)
+	return err
+}
@@ -0,0 +1,126 @@
+package store_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/plural-labs/stakebot/store"
+	"github.com/plural-labs/stakebot/types"
+)
+
+// fill writes n records with two events each
+func fill(t *testing.T, s store.Store, n int) {
+	for i := 0; i < n; i++ {
+		address, err := bech32.ConvertAndEncode("cosmos", []byte(fmt.Sprintf("address%013d", i)))
+		require.NoError(t, err)
+		require.NoError(t, s.SetRecord(&types.Record{
+			ChainId:                chainID,
+			Address:                address,
+			Frequency:              types.Frequency_DAILY,
+			TotalAutostakedRewards: int64(i),
+		}))
+		for j := 1; j <= 2; j++ {
+			require.NoError(t, s.AppendEvent(&types.Event{
+				ChainId:      chainID,
+				Address:      address,
+				UnixNanoTime: int64(j),
+				Outcome:      types.Outcome_SUCCESS,
+			}))
+		}
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	chains := types.ChainRegistry(types.DefaultChains())
+	for _, format := range []string{store.FormatJSON, store.FormatProto} {
+		for name, src := range backends(t) {
+			t.Run(fmt.Sprintf("%s/%s", format, name), func(t *testing.T) {
+				fill(t, src, 5)
+				require.NoError(t, src.SetChainPaused(chainID, true))
+				var buf bytes.Buffer
+				counts, err := store.Export(src, &buf, format)
+				require.NoError(t, err)
+				require.Equal(t, store.Counts{Records: 5, Events: 10, PausedChains: 1}, counts)
+
+				dst := store.NewMemory()
+				counts, err = store.Import(dst, bytes.NewReader(buf.Bytes()), format, chains)
+				require.NoError(t, err)
+				require.Equal(t, store.Counts{Records: 5, Events: 10, PausedChains: 1}, counts)
+				paused, err := dst.PausedChains()
+				require.NoError(t, err)
+				require.Equal(t, []string{chainID}, paused)
+
+				expected, _, err := src.List(store.Filter{})
+				require.NoError(t, err)
+				records, _, err := dst.List(store.Filter{})
+				require.NoError(t, err)
+				require.Len(t, records, len(expected))
+				for i := range records {
+					require.True(t, proto.Equal(expected[i], records[i]))
+					events, err := dst.GetHistory(chainID, records[i].Address, time.Time{}, time.Time{}, 0)
+					require.NoError(t, err)
+					require.Len(t, events, 2)
+				}
+			})
+		}
+	}
+}
+
+func TestImportValidatesChains(t *testing.T) {
+	src := store.NewMemory()
+	fill(t, src, 2)
+	osmosis, err := bech32.ConvertAndEncode("osmo", make([]byte, 20))
+	require.NoError(t, err)
+	require.NoError(t, src.SetRecord(&types.Record{ChainId: "osmosis-1", Address: osmosis, Frequency: types.Frequency_DAILY}))
+
+	var buf bytes.Buffer
+	_, err = store.Export(src, &buf, store.FormatJSON)
+	require.NoError(t, err)
+
+	// the osmosis chain is not configured so nothing is imported
+	dst := store.NewMemory()
+	_, err = store.Import(dst, &buf, store.FormatJSON, types.DefaultChains())
+	require.Error(t, err)
+	count, err := dst.Len()
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	_, err = store.Import(dst, bytes.NewBufferString("{}\n"), store.FormatJSON, types.DefaultChains())
+	require.Error(t, err)
+	_, err = store.Import(dst, bytes.NewBufferString("not json"), store.FormatJSON, types.DefaultChains())
+	require.Error(t, err)
+}
+
+func TestBackupRestore(t *testing.T) {
+	src, err := store.NewBadger(t.TempDir(), types.DefaultStoreConfig())
+	require.NoError(t, err)
+	defer src.Close()
+	fill(t, src, 3)
+	require.NoError(t, src.SetChainPaused(chainID, true))
+
+	var buf bytes.Buffer
+	require.NoError(t, src.Backup(&buf))
+
+	for name, dst := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			counts, err := store.Restore(dst, bytes.NewReader(buf.Bytes()), types.DefaultChains())
+			require.NoError(t, err)
+			require.Equal(t, store.Counts{Records: 3, Events: 6, PausedChains: 1}, counts)
+			count, err := dst.Len()
+			require.NoError(t, err)
+			require.Equal(t, 3, count)
+			paused, err := dst.PausedChains()
+			require.NoError(t, err)
+			require.Equal(t, []string{chainID}, paused)
+		})
+	}
+
+	_, err = store.Restore(store.NewMemory(), bytes.NewBufferString("not a backup"), types.DefaultChains())
+	require.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+var _ Store = &MemoryStore{}
+
+// MemoryStore keeps all records and history in memory. Nothing is persisted once the
+// process exits which makes it mostly useful for tests.
+type MemoryStore struct {
+	mtx sync.RWMutex
+	// both maps are keyed by recordKey
+	records map[string]*types.Record
+	history map[string][]*types.Event
+	paused  map[string]bool
+}
+
+func NewMemory() *MemoryStore {
+	return &MemoryStore{
+		records: make(map[string]*types.Record),
+		history: make(map[string][]*types.Event),
+		paused:  make(map[string]bool),
+	}
+}
+
+func (s *MemoryStore) SetRecord(record *types.Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.records[recordKey(record.ChainId, record.Address)] = proto.Clone(record).(*types.Record)
+	return nil
+}
+
+func (s *MemoryStore) GetRecord(chainID, address string) (*types.Record, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	record, ok := s.records[recordKey(chainID, address)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return proto.Clone(record).(*types.Record), nil
+}
+
+func (s *MemoryStore) DeleteRecord(chainID, address string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.records, recordKey(chainID, address))
+	return nil
+}
+
+func (s *MemoryStore) Update(chainID, address string, fn func(*types.Record) error) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	record, ok := s.records[recordKey(chainID, address)]
+	if !ok {
+		return ErrNotFound
+	}
+	record = proto.Clone(record).(*types.Record)
+	if err := fn(record); err != nil {
+		return err
+	}
+	record.ChainId, record.Address = chainID, address
+	s.records[recordKey(chainID, address)] = record
+	return nil
+}
+
+func (s *MemoryStore) GetRecordsByFrequency(frequency int32) ([]*types.Record, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	records := make([]*types.Record, 0)
+	for _, record := range s.records {
+		if int32(record.Frequency) == frequency {
+			records = append(records, proto.Clone(record).(*types.Record))
+		}
+	}
+	// keep the same ordering as the persistent stores
+	sort.Slice(records, func(i, j int) bool {
+		return recordKey(records[i].ChainId, records[i].Address) < recordKey(records[j].ChainId, records[j].Address)
+	})
+	return records, nil
+}
+
+func (s *MemoryStore) List(filter Filter) ([]*types.Record, string, error) {
+	var after string
+	if filter.Cursor != "" {
+		chainID, address, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		after = recordKey(chainID, address)
+	}
+
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	keys := make([]string, 0, len(s.records))
+	for key := range s.records {
+		if filter.Cursor == "" || key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	page := newPage(filter.Limit)
+	for _, key := range keys {
+		record := s.records[key]
+		if !filter.Match(record) {
+			continue
+		}
+		if !page.add(proto.Clone(record).(*types.Record)) {
+			break
+		}
+	}
+	return page.records, page.next, nil
+}
+
+func (s *MemoryStore) Len() (int, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	return len(s.records), nil
+}
+
+func (s *MemoryStore) AppendEvent(event *types.Event) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	key := recordKey(event.ChainId, event.Address)
+	events := s.history[key]
+	idx := sort.Search(len(events), func(i int) bool { return events[i].UnixNanoTime >= event.UnixNanoTime })
+	event = proto.Clone(event).(*types.Event)
+	if idx < len(events) && events[idx].UnixNanoTime == event.UnixNanoTime {
+		return fmt.Errorf("%w: %s at %d", ErrEventExists, event.Address, event.UnixNanoTime)
+	}
+	events = append(events, nil)
+	copy(events[idx+1:], events[idx:])
+	events[idx] = event
+	s.history[key] = events
+	return nil
+}
+
+func (s *MemoryStore) GetHistory(chainID, address string, from, to time.Time, limit int) ([]*types.Event, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	events := make([]*types.Event, 0)
+	for _, event := range s.history[recordKey(chainID, address)] {
+		if !from.IsZero() && event.UnixNanoTime < from.UnixNano() {
+			continue
+		}
+		if !to.IsZero() && event.UnixNanoTime >= to.UnixNano() {
+			break
+		}
+		if limit > 0 && len(events) >= limit {
+			break
+		}
+		events = append(events, proto.Clone(event).(*types.Event))
+	}
+	return events, nil
+}
+
+func (s *MemoryStore) WalkHistory(fn func(*types.Event) error) error {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	keys := make([]string, 0, len(s.history))
+	for key := range s.history {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, event := range s.history[key] {
+			if err := fn(proto.Clone(event).(*types.Event)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Stats() (Stats, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	stats := Stats{Backend: types.StoreBackendMemory, Records: len(s.records)}
+	for _, events := range s.history {
+		stats.Events += len(events)
+	}
+	return stats, nil
+}
+
+// SetChainPaused pauses or resumes all records of a chain
+func (s *MemoryStore) SetChainPaused(chainID string, paused bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if paused {
+		s.paused[chainID] = true
+	} else {
+		delete(s.paused, chainID)
+	}
+	return nil
+}
+
+// PausedChains returns the ids of the paused chains in order
+func (s *MemoryStore) PausedChains() ([]string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	chains := make([]string, 0, len(s.paused))
+	for chainID := range s.paused {
+		chains = append(chains, chainID)
+	}
+	sort.Strings(chains)
+	return chains, nil
+}
+
+// Version always returns SchemaVersion as nothing is persisted
+func (s *MemoryStore) Version() (int, error) {
+	return SchemaVersion, nil
+}
+
+func (s *MemoryStore) Migrate(_ types.ChainRegistry, dryRun bool) ([]Migration, error) {
+	return []Migration{}, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// recordKey sorts the same way as the chain id, address ordering of the other stores
+func recordKey(chainID, address string) string {
+	return chainID + "\x00" + address
+}
@@ -0,0 +1,393 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+
+	badger "github.com/dgraph-io/badger/v3"
+	"github.com/google/orderedcode"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+// SchemaVersion is the version of the key layout and record encoding written by this
+// version of the stakebot. Every change to either must bump the version and add a
+// migration to each of the persistent backends.
+const SchemaVersion = 3
+
+// Migration describes a single step in upgrading a store to the next schema version.
+type Migration struct {
+	Version     int
+	Description string
+}
+
+// CheckVersion returns an error if the store was written with a different schema
+// version than the one supported by this binary.
+func CheckVersion(s Store) error {
+	version, err := s.Version()
+	if err != nil {
+		return fmt.Errorf("reading store version: %w", err)
+	}
+	switch {
+	case version < SchemaVersion:
+		return fmt.Errorf("store is at version %d but %d is required, run `stakebot migrate` first", version, SchemaVersion)
+	case version > SchemaVersion:
+		return fmt.Errorf("store is at version %d which is newer than the supported version %d, upgrade the stakebot", version, SchemaVersion)
+	}
+	return nil
+}
+
+// Migrations that need to know which chain an address belongs to resolve it through the
+// chain registry of the config.
+type badgerMigration struct {
+	Migration
+	migrate func(txn *badger.Txn, chains types.ChainRegistry) error
+}
+
+// badgerMigrations are ordered by version. The migration at index i upgrades the
+// database from version i to version i+1.
+var badgerMigrations = []badgerMigration{
+	{
+		Migration: Migration{Version: 1, Description: "index records by address"},
+		migrate: func(txn *badger.Txn, _ types.ChainRegistry) error {
+			opts := badger.DefaultIteratorOptions
+			opts.PrefetchValues = false
+			opts.Prefix = []byte{addressPrefix}
+			it := txn.NewIterator(opts)
+			defer it.Close()
+			for it.Rewind(); it.Valid(); it.Next() {
+				var (
+					frequency int64
+					address   string
+				)
+				if _, err := orderedcode.Parse(string(it.Item().Key()[1:]), &frequency, &address); err != nil {
+					return err
+				}
+				if err := txn.Set(mustAppend(indexPrefix, address), indexValue(int32(frequency))); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Migration: Migration{Version: 2, Description: "scope records and history by chain id"},
+		migrate: func(txn *badger.Txn, chains types.ChainRegistry) error {
+			records, err := readPrefix(txn, addressPrefix)
+			if err != nil {
+				return err
+			}
+			for _, kv := range records {
+				var (
+					frequency int64
+					address   string
+				)
+				if _, err := orderedcode.Parse(string(kv.key[1:]), &frequency, &address); err != nil {
+					return err
+				}
+				chain, err := chains.FindChainFromAddress(address)
+				if err != nil {
+					return err
+				}
+				record := new(types.Record)
+				if err := proto.Unmarshal(kv.value, record); err != nil {
+					return err
+				}
+				record.ChainId = chain.Id
+				bz, err := proto.Marshal(record)
+				if err != nil {
+					return err
+				}
+				if err := txn.Delete(kv.key); err != nil {
+					return err
+				}
+				if err := txn.Delete(mustAppend(indexPrefix, address)); err != nil {
+					return err
+				}
+				if err := txn.Set(mustAppend(addressPrefix, frequency, chain.Id, address), bz); err != nil {
+					return err
+				}
+				if err := txn.Set(mustAppend(indexPrefix, chain.Id, address), indexValue(int32(frequency))); err != nil {
+					return err
+				}
+			}
+
+			events, err := readPrefix(txn, historyPrefix)
+			if err != nil {
+				return err
+			}
+			for _, kv := range events {
+				event := new(types.Event)
+				if err := proto.Unmarshal(kv.value, event); err != nil {
+					return err
+				}
+				chain, err := chains.FindChainFromAddress(event.Address)
+				if err != nil {
+					return err
+				}
+				event.ChainId = chain.Id
+				bz, err := proto.Marshal(event)
+				if err != nil {
+					return err
+				}
+				if err := txn.Delete(kv.key); err != nil {
+					return err
+				}
+				if err := txn.Set(mustAppend(historyPrefix, chain.Id, event.Address, event.UnixNanoTime), bz); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Migration: Migration{Version: 3, Description: "move error logs into the error history of records"},
+		migrate: func(txn *badger.Txn, _ types.ChainRegistry) error {
+			records, err := readPrefix(txn, addressPrefix)
+			if err != nil {
+				return err
+			}
+			for _, kv := range records {
+				bz, changed, err := migrateErrorLogs(kv.value)
+				if err != nil {
+					return err
+				}
+				if !changed {
+					continue
+				}
+				if err := txn.Set(kv.key, bz); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// migrateErrorLogs moves the error log of an encoded record into its errors. It returns
+// false if the record has no error log.
+func migrateErrorLogs(bz []byte) ([]byte, bool, error) {
+	record := new(types.Record)
+	if err := proto.Unmarshal(bz, record); err != nil {
+		return nil, false, err
+	}
+	if record.ErrorLogs == "" {
+		return bz, false, nil
+	}
+	// the error happened at or after the last update
+	record.AddError(&types.ErrorEntry{
+		UnixTime: record.LastUpdatedUnixTime,
+		Code:     types.ErrorCode_UNCLASSIFIED,
+		Message:  record.ErrorLogs,
+	})
+	record.ErrorLogs = ""
+	bz, err := proto.Marshal(record)
+	return bz, true, err
+}
+
+type keyValue struct {
+	key, value []byte
+}
+
+// readPrefix copies all pairs under a prefix so that they can be rewritten without
+// iterating over keys written in the same transaction
+func readPrefix(txn *badger.Txn, prefix byte) ([]keyValue, error) {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte{prefix}
+	it := txn.NewIterator(opts)
+	defer it.Close()
+	pairs := make([]keyValue, 0)
+	for it.Rewind(); it.Valid(); it.Next() {
+		value, err := it.Item().ValueCopy(nil)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, keyValue{key: it.Item().KeyCopy(nil), value: value})
+	}
+	return pairs, nil
+}
+
+// mustAppend builds a key in a layout that may have since changed. Migrations use it
+// instead of the current key functions.
+func mustAppend(prefix byte, items ...interface{}) []byte {
+	key, err := orderedcode.Append([]byte{prefix}, items...)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+type sqlMigration struct {
+	Migration
+	migrate func(tx *sql.Tx, chains types.ChainRegistry) error
+}
+
+// sqlMigrations are ordered by version. The migration at index i upgrades the
+// database from version i to version i+1.
+var sqlMigrations = []sqlMigration{
+	{
+		Migration: Migration{Version: 1, Description: "create records and events tables"},
+		migrate: func(tx *sql.Tx, _ types.ChainRegistry) error {
+			for _, stmt := range []string{
+				`CREATE TABLE IF NOT EXISTS records (
+					address TEXT PRIMARY KEY,
+					frequency INTEGER NOT NULL,
+					data BYTEA NOT NULL
+				)`,
+				`CREATE INDEX IF NOT EXISTS records_frequency ON records (frequency)`,
+				`CREATE TABLE IF NOT EXISTS events (
+					address TEXT NOT NULL,
+					unix_nano_time BIGINT NOT NULL,
+					data BYTEA NOT NULL,
+					PRIMARY KEY (address, unix_nano_time)
+				)`,
+			} {
+				if _, err := tx.Exec(stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Migration: Migration{Version: 2, Description: "scope records and history by chain id"},
+		migrate: func(tx *sql.Tx, chains types.ChainRegistry) error {
+			for _, stmt := range []string{
+				`CREATE TABLE records_v2 (
+					chain_id TEXT NOT NULL,
+					address TEXT NOT NULL,
+					frequency INTEGER NOT NULL,
+					data BYTEA NOT NULL,
+					PRIMARY KEY (chain_id, address)
+				)`,
+				`CREATE TABLE events_v2 (
+					chain_id TEXT NOT NULL,
+					address TEXT NOT NULL,
+					unix_nano_time BIGINT NOT NULL,
+					data BYTEA NOT NULL,
+					PRIMARY KEY (chain_id, address, unix_nano_time)
+				)`,
+			} {
+				if _, err := tx.Exec(stmt); err != nil {
+					return err
+				}
+			}
+
+			records, err := readRows(tx, `SELECT data FROM records`)
+			if err != nil {
+				return err
+			}
+			for _, bz := range records {
+				record := new(types.Record)
+				if err := proto.Unmarshal(bz, record); err != nil {
+					return err
+				}
+				chain, err := chains.FindChainFromAddress(record.Address)
+				if err != nil {
+					return err
+				}
+				record.ChainId = chain.Id
+				bz, err = proto.Marshal(record)
+				if err != nil {
+					return err
+				}
+				_, err = tx.Exec(
+					`INSERT INTO records_v2 (chain_id, address, frequency, data) VALUES ($1, $2, $3, $4)`,
+					record.ChainId, record.Address, int32(record.Frequency), bz,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			events, err := readRows(tx, `SELECT data FROM events`)
+			if err != nil {
+				return err
+			}
+			for _, bz := range events {
+				event := new(types.Event)
+				if err := proto.Unmarshal(bz, event); err != nil {
+					return err
+				}
+				chain, err := chains.FindChainFromAddress(event.Address)
+				if err != nil {
+					return err
+				}
+				event.ChainId = chain.Id
+				bz, err = proto.Marshal(event)
+				if err != nil {
+					return err
+				}
+				_, err = tx.Exec(
+					`INSERT INTO events_v2 (chain_id, address, unix_nano_time, data) VALUES ($1, $2, $3, $4)`,
+					event.ChainId, event.Address, event.UnixNanoTime, bz,
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			for _, stmt := range []string{
+				`DROP TABLE records`,
+				`DROP TABLE events`,
+				`ALTER TABLE records_v2 RENAME TO records`,
+				`ALTER TABLE events_v2 RENAME TO events`,
+				`CREATE INDEX records_frequency ON records (frequency)`,
+			} {
+				if _, err := tx.Exec(stmt); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Migration: Migration{Version: 3, Description: "move error logs into the error history of records"},
+		migrate: func(tx *sql.Tx, _ types.ChainRegistry) error {
+			records, err := readRows(tx, `SELECT data FROM records`)
+			if err != nil {
+				return err
+			}
+			for _, bz := range records {
+				bz, changed, err := migrateErrorLogs(bz)
+				if err != nil {
+					return err
+				}
+				if !changed {
+					continue
+				}
+				record := new(types.Record)
+				if err := proto.Unmarshal(bz, record); err != nil {
+					return err
+				}
+				_, err = tx.Exec(
+					`UPDATE records SET data = $1 WHERE chain_id = $2 AND address = $3`,
+					bz, record.ChainId, record.Address,
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// readRows reads a single bytes column from every row of the query
+func readRows(tx *sql.Tx, query string) ([][]byte, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := make([][]byte, 0)
+	for rows.Next() {
+		var bz []byte
+		if err := rows.Scan(&bz); err != nil {
+			return nil, err
+		}
+		values = append(values, bz)
+	}
+	return values, rows.Err()
+}
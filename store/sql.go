@@ -0,0 +1,409 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+var _ Store = &SQLStore{}
+
+// The schema is kept to the subset of SQL understood by both sqlite and postgres.
+// Records and events are stored as marshalled protobufs alongside the columns
+// needed to query them. The tables themselves are created by the migrations.
+const sqlMetadataTable = `CREATE TABLE IF NOT EXISTS metadata (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+)`
+
+// SQLStore persists records in a SQL database. Supported drivers are "sqlite3" and
+// "postgres".
+type SQLStore struct {
+	db     *sql.DB
+	driver string
+}
+
+func NewSQL(driver, dsn string) (*SQLStore, error) {
+	if driver != "sqlite3" && driver != "postgres" {
+		return nil, fmt.Errorf("unsupported sql driver %q", driver)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if driver == "sqlite3" {
+		// sqlite only supports a single writer
+		db.SetMaxOpenConns(1)
+	}
+	if _, err := db.Exec(sqlMetadataTable); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating metadata table: %w", err)
+	}
+	s := &SQLStore{db: db, driver: driver}
+
+	// a database without a records table is new and can be brought straight to the
+	// latest version
+	if _, err := db.Exec(`SELECT COUNT(*) FROM records`); err != nil {
+		if _, err := s.Migrate(nil, false); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s SQLStore) Version() (int, error) {
+	var value string
+	err := s.db.QueryRow(`SELECT value FROM metadata WHERE key = 'version'`).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// Migrate runs all migrations from the current version up to SchemaVersion. Each migration
+// is committed in its own transaction together with the new version. A dry run runs all
+// migrations in a single transaction that is then rolled back.
+func (s SQLStore) Migrate(chains types.ChainRegistry, dryRun bool) ([]Migration, error) {
+	version, err := s.Version()
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("store version %d is newer than the supported version %d", version, SchemaVersion)
+	}
+
+	applied := make([]Migration, 0)
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+	for _, m := range sqlMigrations[version:] {
+		if err := m.migrate(tx, chains); err != nil {
+			return applied, fmt.Errorf("migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		_, err := tx.Exec(
+			`INSERT INTO metadata (key, value) VALUES ('version', $1)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value`,
+			strconv.Itoa(m.Version),
+		)
+		if err != nil {
+			return applied, err
+		}
+		if !dryRun {
+			if err := tx.Commit(); err != nil {
+				return applied, fmt.Errorf("committing migration %d: %w", m.Version, err)
+			}
+			tx, err = s.db.Begin()
+			if err != nil {
+				return applied, err
+			}
+		}
+		applied = append(applied, m.Migration)
+	}
+	return applied, nil
+}
+
+func (s SQLStore) SetRecord(record *types.Record) error {
+	return setSQLRecord(s.db, record)
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func setSQLRecord(db execer, record *types.Record) error {
+	bz, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		`INSERT INTO records (chain_id, address, frequency, data) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain_id, address) DO UPDATE SET frequency = excluded.frequency, data = excluded.data`,
+		record.ChainId, record.Address, int32(record.Frequency), bz,
+	)
+	return err
+}
+
+func (s SQLStore) GetRecord(chainID, address string) (*types.Record, error) {
+	var bz []byte
+	err := s.db.QueryRow(`SELECT data FROM records WHERE chain_id = $1 AND address = $2`, chainID, address).Scan(&bz)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := new(types.Record)
+	if err := proto.Unmarshal(bz, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s SQLStore) DeleteRecord(chainID, address string) error {
+	_, err := s.db.Exec(`DELETE FROM records WHERE chain_id = $1 AND address = $2`, chainID, address)
+	return err
+}
+
+// Update runs within a transaction. With postgres the row is locked for the duration of
+// the transaction while sqlite only ever allows a single connection.
+func (s SQLStore) Update(chainID, address string, fn func(*types.Record) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `SELECT data FROM records WHERE chain_id = $1 AND address = $2`
+	if s.driver == "postgres" {
+		query += ` FOR UPDATE`
+	}
+	var bz []byte
+	err = tx.QueryRow(query, chainID, address).Scan(&bz)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	record := new(types.Record)
+	if err := proto.Unmarshal(bz, record); err != nil {
+		return err
+	}
+	if err := fn(record); err != nil {
+		return err
+	}
+	record.ChainId, record.Address = chainID, address
+	if err := setSQLRecord(tx, record); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s SQLStore) GetRecordsByFrequency(frequency int32) ([]*types.Record, error) {
+	rows, err := s.db.Query(`SELECT data FROM records WHERE frequency = $1 ORDER BY chain_id, address`, frequency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := make([]*types.Record, 0)
+	for rows.Next() {
+		var bz []byte
+		if err := rows.Scan(&bz); err != nil {
+			return nil, err
+		}
+		record := new(types.Record)
+		if err := proto.Unmarshal(bz, record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+// List narrows down the rows by chain, frequency and cursor in SQL. The remaining
+// filters are applied to the decoded records.
+func (s SQLStore) List(filter Filter) ([]*types.Record, string, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if filter.ChainID != "" {
+		conditions = append(conditions, "chain_id = "+arg(filter.ChainID))
+	}
+	if filter.Frequency != types.Frequency_UNKNOWN {
+		conditions = append(conditions, "frequency = "+arg(int32(filter.Frequency)))
+	}
+	if filter.Cursor != "" {
+		chainID, address, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		chainArg := arg(chainID)
+		conditions = append(conditions, fmt.Sprintf("(chain_id > %s OR (chain_id = %s AND address > %s))", chainArg, chainArg, arg(address)))
+	}
+	query := `SELECT data FROM records`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY chain_id, address"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	page := newPage(filter.Limit)
+	for rows.Next() {
+		var bz []byte
+		if err := rows.Scan(&bz); err != nil {
+			return nil, "", err
+		}
+		record := new(types.Record)
+		if err := proto.Unmarshal(bz, record); err != nil {
+			return nil, "", err
+		}
+		if !filter.Match(record) {
+			continue
+		}
+		if !page.add(record) {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	return page.records, page.next, nil
+}
+
+func (s SQLStore) Len() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM records`).Scan(&count)
+	return count, err
+}
+
+// sqlPausedPrefix is followed by the id of every paused chain in the metadata keys
+const sqlPausedPrefix = "paused/"
+
+func (s SQLStore) SetChainPaused(chainID string, paused bool) error {
+	if !paused {
+		_, err := s.db.Exec(`DELETE FROM metadata WHERE key = $1`, sqlPausedPrefix+chainID)
+		return err
+	}
+	_, err := s.db.Exec(
+		`INSERT INTO metadata (key, value) VALUES ($1, 'true')
+		ON CONFLICT (key) DO NOTHING`,
+		sqlPausedPrefix+chainID,
+	)
+	return err
+}
+
+func (s SQLStore) PausedChains() ([]string, error) {
+	rows, err := s.db.Query(`SELECT key FROM metadata WHERE key LIKE $1 ORDER BY key`, sqlPausedPrefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	chains := make([]string, 0)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		chains = append(chains, strings.TrimPrefix(key, sqlPausedPrefix))
+	}
+	return chains, rows.Err()
+}
+
+func (s SQLStore) Stats() (Stats, error) {
+	stats := Stats{Backend: types.StoreBackendSQL}
+	err := s.db.QueryRow(`SELECT (SELECT COUNT(*) FROM records), (SELECT COUNT(*) FROM events)`).Scan(&stats.Records, &stats.Events)
+	return stats, err
+}
+
+func (s SQLStore) AppendEvent(event *types.Event) error {
+	bz, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+	result, err := s.db.Exec(
+		`INSERT INTO events (chain_id, address, unix_nano_time, data) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chain_id, address, unix_nano_time) DO NOTHING`,
+		event.ChainId, event.Address, event.UnixNanoTime, bz,
+	)
+	if err != nil {
+		return err
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return fmt.Errorf("%w: %s at %d", ErrEventExists, event.Address, event.UnixNanoTime)
+	}
+	return nil
+}
+
+func (s SQLStore) GetHistory(chainID, address string, from, to time.Time, limit int) ([]*types.Event, error) {
+	var start, end int64 = 0, math.MaxInt64
+	if !from.IsZero() {
+		start = from.UnixNano()
+	}
+	if !to.IsZero() {
+		end = to.UnixNano()
+	}
+	query := `SELECT data FROM events WHERE chain_id = $1 AND address = $2 AND unix_nano_time >= $3 AND unix_nano_time < $4 ORDER BY unix_nano_time`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	rows, err := s.db.Query(query, chainID, address, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*types.Event, 0)
+	for rows.Next() {
+		var bz []byte
+		if err := rows.Scan(&bz); err != nil {
+			return nil, err
+		}
+		event := new(types.Event)
+		if err := proto.Unmarshal(bz, event); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func (s SQLStore) WalkHistory(fn func(*types.Event) error) error {
+	rows, err := s.db.Query(`SELECT data FROM events ORDER BY chain_id, address, unix_nano_time`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bz []byte
+		if err := rows.Scan(&bz); err != nil {
+			return err
+		}
+		event := new(types.Event)
+		if err := proto.Unmarshal(bz, event); err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s SQLStore) Close() error {
+	return s.db.Close()
+}
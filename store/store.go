@@ -1,152 +1,175 @@
 package store
 
 import (
-	"path/filepath"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
 
-	badger "github.com/dgraph-io/badger/v3"
 	"github.com/google/orderedcode"
-	"google.golang.org/protobuf/proto"
 
 	"github.com/plural-labs/stakebot/types"
 )
 
-const (
-	defaultStoreName = "store.db"
+// ErrNotFound is returned when no record exists for the requested chain and address.
+var ErrNotFound = errors.New("record not found")
 
-	addressPrefix = byte(0x00)
-)
+// ErrEventExists is returned when appending an event at the same time as an existing
+// event of the same address, as the history is never modified once written.
+var ErrEventExists = errors.New("event already exists")
+
+// ErrInvalidCursor is returned when listing with a cursor that was not produced by List.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Store persists the records of all addresses served by the stakebot as well as the
+// history of every restake attempt made on their behalf. Records are identified by
+// chain id and address.
+type Store interface {
+	SetRecord(record *types.Record) error
+	GetRecord(chainID, address string) (*types.Record, error)
+	DeleteRecord(chainID, address string) error
+	// Update atomically reads, modifies and writes back the record of an address.
+	// It returns ErrNotFound if no record exists. fn should have no side effects as
+	// it may be called more than once.
+	Update(chainID, address string, fn func(*types.Record) error) error
+	GetRecordsByFrequency(frequency int32) ([]*types.Record, error)
+	// List returns the records matching the filter ordered by chain id and address,
+	// along with a cursor to the next page which is empty on the last page.
+	List(filter Filter) ([]*types.Record, string, error)
+	Len() (int, error)
+
+	// AppendEvent adds an event to the history of its address. It returns ErrEventExists
+	// if the address already has an event at the same time.
+	AppendEvent(event *types.Event) error
+	GetHistory(chainID, address string, from, to time.Time, limit int) ([]*types.Event, error)
+	// WalkHistory calls fn for every event of every address ordered by chain id, address
+	// and time, stopping at the first error. fn must not write to the store.
+	WalkHistory(fn func(*types.Event) error) error
+
+	// Stats reports the amount of data kept by the store
+	Stats() (Stats, error)
+
+	// SetChainPaused pauses or resumes restaking the records of a chain
+	SetChainPaused(chainID string, paused bool) error
+	// PausedChains returns the ids of all paused chains in order
+	PausedChains() ([]string, error)
+
+	// Version returns the schema version the persisted data was written with
+	Version() (int, error)
+	// Migrate upgrades the persisted data to SchemaVersion and returns the migrations
+	// that were applied. A dry run returns the same without writing any changes.
+	Migrate(chains types.ChainRegistry, dryRun bool) ([]Migration, error)
 
-type Store struct {
-	db *badger.DB
+	Close() error
 }
 
-func New(dir string) (*Store, error) {
-	path := filepath.Join(dir, defaultStoreName)
-	db, err := badger.Open(badger.DefaultOptions(path))
-	if err != nil {
-		return nil, err
+// New opens the store backend selected in the config. Badger is used when no backend
+// is specified, in which case the database is kept in dir.
+func New(dir string, cfg types.StoreConfig) (Store, error) {
+	switch cfg.Backend {
+	case "", types.StoreBackendBadger:
+		return NewBadger(dir, cfg)
+	case types.StoreBackendMemory:
+		return NewMemory(), nil
+	case types.StoreBackendSQL:
+		return NewSQL(cfg.Driver, cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown store backend %q", cfg.Backend)
 	}
-	return &Store{
-		db: db,
-	}, nil
 }
 
-func (s Store) SetRecord(record *types.Record) error {
-	// if the address exists elsewhere we remove it
-	err := s.DeleteRecord(record.Address)
-	if err != nil {
-		return err
-	}
-	return s.db.Update(func(txn *badger.Txn) error {
-		bz, err := proto.Marshal(record)
-		if err != nil {
-			return err
-		}
-		return txn.Set(key(int32(record.Frequency), record.Address), bz)
-	})
+// Stats describes the contents of a store. The sizes on disk and garbage collection
+// statistics are only reported by the badger backend.
+type Stats struct {
+	Backend string `json:"backend"`
+	Records int    `json:"records"`
+	Events  int    `json:"events"`
+
+	LSMSize      int64     `json:"lsm_size,omitempty"`
+	ValueLogSize int64     `json:"value_log_size,omitempty"`
+	LastGC       time.Time `json:"last_gc"`
+	GCRewrites   int       `json:"gc_rewrites,omitempty"`
 }
 
-func (s Store) GetRecord(address string) (*types.Record, error) {
-	record := new(types.Record)
-	err := s.db.View(func(txn *badger.Txn) error {
-		var (
-			item *badger.Item
-			err  error
-		)
-		// iterate over all the possible frequencies
-		for frequency := int32(1); frequency <= 4; frequency++ {
-			item, err = txn.Get(key(frequency, address))
-			if err != nil && err != badger.ErrKeyNotFound {
-				return err
-			}
-			if item != nil {
-				break
-			}
-		}
-		// unable to find the address at any frequency
-		if err != nil {
-			return err
-		}
-
-		// unmarshal value
-		return item.Value(func(val []byte) error {
-			return proto.Unmarshal(val, record)
-		})
-	})
-	if err != nil {
-		return nil, err
-	}
-	return record, nil
+// Filter selects records in List. Zero values match all records.
+type Filter struct {
+	ChainID   string
+	Frequency types.Frequency
+	// HasError when set only matches records whose last attempt failed (true) or
+	// succeeded (false)
+	HasError *bool
+	// UpdatedAfter (inclusive) and UpdatedBefore (exclusive) bound the time the
+	// record was last restaked
+	UpdatedAfter  time.Time
+	UpdatedBefore time.Time
+
+	// Cursor continues a previous listing. Limit caps the size of the page, zero or
+	// less returns all records.
+	Cursor string
+	Limit  int
 }
 
-func (s Store) DeleteRecord(address string) error {
-	return s.db.Update(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		for it.Seek([]byte{addressPrefix}); it.Valid(); it.Next() {
-			err := txn.Delete(it.Item().Key())
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+// Match reports whether the record passes the filter. The cursor and limit are not
+// taken into account.
+func (f Filter) Match(record *types.Record) bool {
+	if f.ChainID != "" && record.ChainId != f.ChainID {
+		return false
+	}
+	if f.Frequency != types.Frequency_UNKNOWN && record.Frequency != f.Frequency {
+		return false
+	}
+	if f.HasError != nil && *f.HasError != record.Failing() {
+		return false
+	}
+	if !f.UpdatedAfter.IsZero() && record.LastUpdatedUnixTime < f.UpdatedAfter.Unix() {
+		return false
+	}
+	if !f.UpdatedBefore.IsZero() && record.LastUpdatedUnixTime >= f.UpdatedBefore.Unix() {
+		return false
+	}
+	return true
 }
 
-func (s Store) GetRecordsByFrequency(frequency int32) ([]*types.Record, error) {
-	records := make([]*types.Record, 0)
-	err := s.db.View(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		prefix, err := orderedcode.Append([]byte{addressPrefix}, int64(frequency))
-		if err != nil {
-			panic(err)
-		}
-		for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
-			record := new(types.Record)
-			item := it.Item()
-			err := item.Value(func(v []byte) error {
-				if err := proto.Unmarshal(v, record); err != nil {
-					return err
-				}
-				records = append(records, record)
-				return nil
-			})
-			if err != nil {
-				return err
-			}
-		}
-		return nil
-	})
-	return records, err
+// page collects the records of a listing. Records must be added in order and add
+// returns false once the page is full.
+type page struct {
+	limit   int
+	records []*types.Record
+	next    string
 }
 
-func (s Store) Close() error {
-	return s.db.Close()
+func newPage(limit int) *page {
+	return &page{limit: limit, records: make([]*types.Record, 0)}
 }
 
-func (s Store) Len() (int, error) {
-	recordCount := 0
-	err := s.db.Update(func(txn *badger.Txn) error {
-		opts := badger.DefaultIteratorOptions
-		opts.PrefetchValues = false
-		it := txn.NewIterator(opts)
-		defer it.Close()
-		for it.Seek([]byte{addressPrefix}); it.Valid(); it.Next() {
-			recordCount++
-		}
-		return nil
-	})
-	return recordCount, err
+func (p *page) add(record *types.Record) bool {
+	if p.limit > 0 && len(p.records) == p.limit {
+		// there is at least one more record so the last one becomes the cursor
+		last := p.records[len(p.records)-1]
+		p.next = encodeCursor(last.ChainId, last.Address)
+		return false
+	}
+	p.records = append(p.records, record)
+	return true
 }
 
-func key(frequency int32, address string) []byte {
-	key, err := orderedcode.Append([]byte{addressPrefix}, int64(frequency), address)
+func encodeCursor(chainID, address string) string {
+	bz, err := orderedcode.Append(nil, chainID, address)
 	if err != nil {
 		panic(err)
 	}
-	return key
+	return base64.RawURLEncoding.EncodeToString(bz)
+}
+
+// decodeCursor returns the chain id and address of the last record of the previous page
+func decodeCursor(cursor string) (string, string, error) {
+	bz, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	var chainID, address string
+	if _, err := orderedcode.Parse(string(bz), &chainID, &address); err != nil {
+		return "", "", fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return chainID, address, nil
 }
@@ -1,33 +1,70 @@
 package store_test
 
 import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 	badger "github.com/dgraph-io/badger/v3"
+	"github.com/google/orderedcode"
 	"github.com/plural-labs/stakebot/store"
 	"github.com/plural-labs/stakebot/types"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
-func TestRecords(t *testing.T) {
-	db, err := store.New(t.TempDir())
+const chainID = "cosmoshub-4"
+
+// backends returns a fresh instance of every store implementation
+func backends(t *testing.T) map[string]store.Store {
+	badgerStore, err := store.NewBadger(t.TempDir(), types.DefaultStoreConfig())
+	require.NoError(t, err)
+	sqlStore, err := store.NewSQL("sqlite3", filepath.Join(t.TempDir(), "store.sqlite"))
 	require.NoError(t, err)
+	stores := map[string]store.Store{
+		"badger": badgerStore,
+		"memory": store.NewMemory(),
+		"sql":    sqlStore,
+	}
+	t.Cleanup(func() {
+		for _, s := range stores {
+			require.NoError(t, s.Close())
+		}
+	})
+	return stores
+}
 
+func TestRecords(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testRecords(t, db)
+		})
+	}
+}
+
+func testRecords(t *testing.T, db store.Store) {
 	record := &types.Record{
+		ChainId:   chainID,
 		Address:   "address1",
 		Frequency: types.Frequency_DAILY,
 		Tolerance: 1000,
 	}
-	_, err = db.GetRecord(record.Address)
-	require.Equal(t, badger.ErrKeyNotFound, err)
+	_, err := db.GetRecord(chainID, record.Address)
+	require.Equal(t, store.ErrNotFound, err)
 
 	require.NoError(t, db.SetRecord(record))
 
-	out, err := db.GetRecord(record.Address)
+	out, err := db.GetRecord(chainID, record.Address)
 	require.NoError(t, err)
 	require.Equal(t, record.Address, out.Address)
 
 	require.NoError(t, db.SetRecord(&types.Record{
+		ChainId:   chainID,
 		Address:   "address2",
 		Frequency: types.Frequency_DAILY,
 		Tolerance: 5000,
@@ -50,8 +87,463 @@ func TestRecords(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, records, 1)
 
-	require.NoError(t, db.DeleteRecord("address2"))
+	require.NoError(t, db.DeleteRecord(chainID, "address2"))
+
+	_, err = db.GetRecord(chainID, "address2")
+	require.Equal(t, store.ErrNotFound, err)
+}
+
+func TestRecordsAreScopedByChain(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, id := range []string{"cosmoshub-4", "theta-testnet-001"} {
+				require.NoError(t, db.SetRecord(&types.Record{
+					ChainId:   id,
+					Address:   "address1",
+					Frequency: types.Frequency_DAILY,
+				}))
+				require.NoError(t, db.AppendEvent(&types.Event{
+					ChainId:      id,
+					Address:      "address1",
+					UnixNanoTime: 1,
+				}))
+			}
+			count, err := db.Len()
+			require.NoError(t, err)
+			require.Equal(t, 2, count)
+
+			require.NoError(t, db.DeleteRecord("theta-testnet-001", "address1"))
+			_, err = db.GetRecord("theta-testnet-001", "address1")
+			require.Equal(t, store.ErrNotFound, err)
+			record, err := db.GetRecord("cosmoshub-4", "address1")
+			require.NoError(t, err)
+			require.Equal(t, "cosmoshub-4", record.ChainId)
+
+			events, err := db.GetHistory("theta-testnet-001", "address1", time.Time{}, time.Time{}, 0)
+			require.NoError(t, err)
+			require.Len(t, events, 1)
+			require.Equal(t, "theta-testnet-001", events[0].ChainId)
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testList(t, db)
+		})
+	}
+}
+
+func testList(t *testing.T, db store.Store) {
+	chains := []string{"cosmoshub-4", "osmosis-1"}
+	frequencies := []types.Frequency{types.Frequency_DAILY, types.Frequency_WEEKLY}
+	for i := 0; i < 10; i++ {
+		record := &types.Record{
+			ChainId:             chains[i%2],
+			Address:             fmt.Sprintf("address%d", i),
+			Frequency:           frequencies[(i/2)%2],
+			LastUpdatedUnixTime: int64(i * 100),
+		}
+		if i%3 == 0 {
+			record.AddError(&types.ErrorEntry{UnixTime: record.LastUpdatedUnixTime, Message: "failed"})
+		}
+		require.NoError(t, db.SetRecord(record))
+	}
+
+	records, next, err := db.List(store.Filter{})
+	require.NoError(t, err)
+	require.Len(t, records, 10)
+	require.Empty(t, next)
+	// ordered by chain and then address
+	require.Equal(t, "cosmoshub-4", records[0].ChainId)
+	require.Equal(t, "address0", records[0].Address)
+	require.Equal(t, "osmosis-1", records[9].ChainId)
+	require.Equal(t, "address9", records[9].Address)
+
+	// page through all records
+	var (
+		cursor string
+		paged  []*types.Record
+	)
+	for {
+		records, next, err = db.List(store.Filter{Cursor: cursor, Limit: 3})
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(records), 3)
+		paged = append(paged, records...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	require.Len(t, paged, 10)
+	require.Equal(t, "address9", paged[9].Address)
+
+	// a full last page has no cursor
+	records, next, err = db.List(store.Filter{ChainID: "cosmoshub-4", Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	require.Empty(t, next)
+
+	records, _, err = db.List(store.Filter{ChainID: "osmosis-1", Frequency: types.Frequency_WEEKLY})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	for _, record := range records {
+		require.Equal(t, "osmosis-1", record.ChainId)
+		require.Equal(t, types.Frequency_WEEKLY, record.Frequency)
+	}
+
+	hasError := true
+	records, _, err = db.List(store.Filter{HasError: &hasError})
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+	hasError = false
+	records, _, err = db.List(store.Filter{HasError: &hasError})
+	require.NoError(t, err)
+	require.Len(t, records, 6)
+
+	records, _, err = db.List(store.Filter{UpdatedAfter: time.Unix(200, 0), UpdatedBefore: time.Unix(500, 0)})
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// filters and cursors combine
+	records, next, err = db.List(store.Filter{ChainID: "osmosis-1", Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.NotEmpty(t, next)
+	records, next, err = db.List(store.Filter{ChainID: "osmosis-1", Limit: 2, Cursor: next})
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "address5", records[0].Address)
+
+	_, _, err = db.List(store.Filter{Cursor: "not a cursor"})
+	require.ErrorIs(t, err, store.ErrInvalidCursor)
+}
+
+func TestUpdate(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testUpdate(t, db)
+		})
+	}
+}
+
+func testUpdate(t *testing.T, db store.Store) {
+	err := db.Update(chainID, "address1", func(r *types.Record) error { return nil })
+	require.Equal(t, store.ErrNotFound, err)
+
+	require.NoError(t, db.SetRecord(&types.Record{
+		ChainId:   chainID,
+		Address:   "address1",
+		Frequency: types.Frequency_MONTHLY,
+	}))
+	require.NoError(t, db.SetRecord(&types.Record{
+		ChainId:   chainID,
+		Address:   "address2",
+		Frequency: types.Frequency_MONTHLY,
+	}))
+
+	// monthly records can be found
+	record, err := db.GetRecord(chainID, "address1")
+	require.NoError(t, err)
+	require.Equal(t, types.Frequency_MONTHLY, record.Frequency)
+
+	// concurrent updates must not overwrite one another
+	const updates = 20
+	var wg sync.WaitGroup
+	for i := 0; i < updates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, db.Update(chainID, "address1", func(r *types.Record) error {
+				r.TotalAutostakedRewards++
+				return nil
+			}))
+		}()
+	}
+	wg.Wait()
+
+	record, err = db.GetRecord(chainID, "address1")
+	require.NoError(t, err)
+	require.Equal(t, int64(updates), record.TotalAutostakedRewards)
+
+	// changing the frequency moves the record
+	require.NoError(t, db.Update(chainID, "address1", func(r *types.Record) error {
+		r.Frequency = types.Frequency_HOURLY
+		return nil
+	}))
+	records, err := db.GetRecordsByFrequency(int32(types.Frequency_MONTHLY))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	require.Equal(t, "address2", records[0].Address)
+	records, err = db.GetRecordsByFrequency(int32(types.Frequency_HOURLY))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// an error aborts the update
+	errAbort := errors.New("abort")
+	err = db.Update(chainID, "address1", func(r *types.Record) error {
+		r.Tolerance = 100
+		return errAbort
+	})
+	require.Equal(t, errAbort, err)
+	record, err = db.GetRecord(chainID, "address1")
+	require.NoError(t, err)
+	require.Zero(t, record.Tolerance)
+
+	count, err := db.Len()
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestHistory(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			testHistory(t, db)
+		})
+	}
+}
+
+func testHistory(t *testing.T, db store.Store) {
+	start := time.Unix(1640000000, 0)
+	for i := 4; i >= 0; i-- {
+		require.NoError(t, db.AppendEvent(&types.Event{
+			ChainId:      chainID,
+			Address:      "address1",
+			UnixNanoTime: start.Add(time.Duration(i) * time.Hour).UnixNano(),
+			Outcome:      types.Outcome_SUCCESS,
+			TotalRewards: int64(i),
+		}))
+	}
+	require.NoError(t, db.AppendEvent(&types.Event{
+		ChainId:      chainID,
+		Address:      "address2",
+		UnixNanoTime: start.UnixNano(),
+		Outcome:      types.Outcome_FAILURE,
+	}))
+
+	events, err := db.GetHistory(chainID, "address1", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 5)
+	for i, event := range events {
+		require.Equal(t, int64(i), event.TotalRewards)
+	}
+
+	// from is inclusive and to is exclusive
+	events, err = db.GetHistory(chainID, "address1", start.Add(time.Hour), start.Add(3*time.Hour), 0)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, int64(1), events[0].TotalRewards)
+
+	events, err = db.GetHistory(chainID, "address1", start.Add(time.Hour), time.Time{}, 3)
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.Equal(t, int64(3), events[2].TotalRewards)
+
+	events, err = db.GetHistory(chainID, "address2", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, types.Outcome_FAILURE, events[0].Outcome)
+
+	// events are never replaced, even by one at the same time
+	err = db.AppendEvent(&types.Event{
+		ChainId:      chainID,
+		Address:      "address2",
+		UnixNanoTime: start.UnixNano(),
+		Outcome:      types.Outcome_SUCCESS,
+	})
+	require.ErrorIs(t, err, store.ErrEventExists)
+	events, err = db.GetHistory(chainID, "address2", time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, types.Outcome_FAILURE, events[0].Outcome)
+
+	// history is not counted as records
+	count, err := db.Len()
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
+
+func TestMigrateBadger(t *testing.T) {
+	dir := t.TempDir()
+	chains := types.DefaultChains()
+	address, err := bech32.ConvertAndEncode("cosmos", make([]byte, 20))
+	require.NoError(t, err)
+
+	// write a record and event using the original layout, without index or version
+	db, err := badger.Open(badger.DefaultOptions(filepath.Join(dir, "store.db")))
+	require.NoError(t, err)
+	recordKey, err := orderedcode.Append([]byte{0x00}, int64(types.Frequency_MONTHLY), address)
+	require.NoError(t, err)
+	record, err := proto.Marshal(&types.Record{Address: address, Frequency: types.Frequency_MONTHLY, LastUpdatedUnixTime: 100, ErrorLogs: "failed"})
+	require.NoError(t, err)
+	eventKey, err := orderedcode.Append([]byte{0x01}, address, int64(1))
+	require.NoError(t, err)
+	event, err := proto.Marshal(&types.Event{Address: address, UnixNanoTime: 1})
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(recordKey, record); err != nil {
+			return err
+		}
+		return txn.Set(eventKey, event)
+	}))
+	require.NoError(t, db.Close())
+
+	s, err := store.NewBadger(dir, types.DefaultStoreConfig())
+	require.NoError(t, err)
+	defer s.Close()
+	testMigrate(t, s, chains, address, 0)
+}
+
+func TestMigrateSQL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.sqlite")
+	chains := types.DefaultChains()
+	address, err := bech32.ConvertAndEncode("cosmos", make([]byte, 20))
+	require.NoError(t, err)
+
+	// create a version 1 database, before records were scoped by chain
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	record, err := proto.Marshal(&types.Record{Address: address, Frequency: types.Frequency_MONTHLY, LastUpdatedUnixTime: 100, ErrorLogs: "failed"})
+	require.NoError(t, err)
+	event, err := proto.Marshal(&types.Event{Address: address, UnixNanoTime: 1})
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE metadata (key TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+		`INSERT INTO metadata (key, value) VALUES ('version', '1')`,
+		`CREATE TABLE records (address TEXT PRIMARY KEY, frequency INTEGER NOT NULL, data BYTEA NOT NULL)`,
+		`CREATE INDEX records_frequency ON records (frequency)`,
+		`CREATE TABLE events (address TEXT NOT NULL, unix_nano_time BIGINT NOT NULL, data BYTEA NOT NULL, PRIMARY KEY (address, unix_nano_time))`,
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	_, err = db.Exec(`INSERT INTO records (address, frequency, data) VALUES ($1, $2, $3)`, address, int32(types.Frequency_MONTHLY), record)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO events (address, unix_nano_time, data) VALUES ($1, $2, $3)`, address, 1, event)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	s, err := store.NewSQL("sqlite3", path)
+	require.NoError(t, err)
+	defer s.Close()
+	testMigrate(t, s, chains, address, 1)
+}
+
+// testMigrate expects a store at version with a single monthly record for address
+// on the first of chains
+func testMigrate(t *testing.T, s store.Store, chains types.ChainRegistry, address string, version int) {
+	chainID := chains[0].Id
+	current, err := s.Version()
+	require.NoError(t, err)
+	require.Equal(t, version, current)
+	require.Error(t, store.CheckVersion(s))
+
+	// a dry run reports the migrations without applying them
+	migrations, err := s.Migrate(chains, true)
+	require.NoError(t, err)
+	require.Len(t, migrations, store.SchemaVersion-version)
+	current, err = s.Version()
+	require.NoError(t, err)
+	require.Equal(t, version, current)
+	_, err = s.GetRecord(chainID, address)
+	require.Error(t, err)
+
+	migrations, err = s.Migrate(chains, false)
+	require.NoError(t, err)
+	require.Len(t, migrations, store.SchemaVersion-version)
+	require.NoError(t, store.CheckVersion(s))
+
+	record, err := s.GetRecord(chainID, address)
+	require.NoError(t, err)
+	require.Equal(t, types.Frequency_MONTHLY, record.Frequency)
+	require.Equal(t, chainID, record.ChainId)
+	require.Empty(t, record.ErrorLogs)
+	require.Len(t, record.Errors, 1)
+	require.Equal(t, "failed", record.Errors[0].Message)
+	require.True(t, record.Failing())
+
+	events, err := s.GetHistory(chainID, address, time.Time{}, time.Time{}, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, chainID, events[0].ChainId)
+
+	records, err := s.GetRecordsByFrequency(int32(types.Frequency_MONTHLY))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// migrating again is a no-op
+	migrations, err = s.Migrate(chains, false)
+	require.NoError(t, err)
+	require.Len(t, migrations, 0)
+}
+
+func TestNewStoresAreCurrent(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, store.CheckVersion(db))
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			fill(t, db, 3)
+			stats, err := db.Stats()
+			require.NoError(t, err)
+			require.Equal(t, name, stats.Backend)
+			require.Equal(t, 3, stats.Records)
+			require.Equal(t, 6, stats.Events)
+		})
+	}
+}
+
+func TestPausedChains(t *testing.T) {
+	for name, db := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			chains, err := db.PausedChains()
+			require.NoError(t, err)
+			require.Empty(t, chains)
+
+			require.NoError(t, db.SetChainPaused("osmosis-1", true))
+			require.NoError(t, db.SetChainPaused("cosmoshub-4", true))
+			// pausing twice is a no-op
+			require.NoError(t, db.SetChainPaused("cosmoshub-4", true))
+			chains, err = db.PausedChains()
+			require.NoError(t, err)
+			require.Equal(t, []string{"cosmoshub-4", "osmosis-1"}, chains)
+
+			require.NoError(t, db.SetChainPaused("osmosis-1", false))
+			require.NoError(t, db.SetChainPaused("juno-1", false))
+			chains, err = db.PausedChains()
+			require.NoError(t, err)
+			require.Equal(t, []string{"cosmoshub-4"}, chains)
+
+			// the schema version is kept apart from the paused chains
+			version, err := db.Version()
+			require.NoError(t, err)
+			require.Equal(t, store.SchemaVersion, version)
+		})
+	}
+}
+
+func TestBadgerGC(t *testing.T) {
+	cfg := types.DefaultStoreConfig()
+	cfg.GCInterval = types.Duration{Duration: 10 * time.Millisecond}
+	db, err := store.NewBadger(t.TempDir(), cfg)
+	require.NoError(t, err)
+	fill(t, db, 3)
+
+	require.Eventually(t, func() bool {
+		stats, err := db.Stats()
+		return err == nil && !stats.LastGC.IsZero()
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, db.Close())
+	// closing twice does not panic on the stopped gc loop
+	require.NoError(t, db.Close())
 
-	_, err = db.GetRecord("address2")
-	require.Equal(t, badger.ErrKeyNotFound, err)
+	cfg.GCDiscardRatio = 1
+	_, err = store.NewBadger(t.TempDir(), cfg)
+	require.Error(t, err)
 }
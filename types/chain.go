@@ -2,33 +2,113 @@ package types
 
 import (
 	"fmt"
+	"math"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/cosmos/cosmos-sdk/types/bech32"
 )
 
 type Config struct {
 	Chains     ChainRegistry
 	ListenAddr string `toml:"listen_addr"`
+	// AdminToken authenticates requests to the /v1/admin endpoints. They are disabled
+	// when no token is set.
+	AdminToken string      `toml:"admin_token"`
+	Store      StoreConfig `toml:"store"`
+	Bot        BotConfig   `toml:"bot"`
 }
 
 func DefaultConfig() Config {
-	return Config{ListenAddr: "localhost:8000", Chains: DefaultChains()}
+	return Config{ListenAddr: "localhost:8000", Chains: DefaultChains(), Store: DefaultStoreConfig(), Bot: DefaultBotConfig()}
+}
+
+// BotConfig tunes how records are restaked. Workers bounds the amount of records
+// restaked at the same time across all chains. RestakeTimeout bounds the time a single
+// restake may take, including waiting for its transaction to be included in a block.
+//
+// Restakes failing with a transient error are retried up to Retries times within a run,
+// waiting RetryBackoff before the first retry and doubling it for every following one.
+// Records failing SuspendAfter runs in a row are suspended, zero never suspends.
+//
+// Records that missed a run while the bot was down are restaked once it starts, at most
+// CatchUpLimit at a time. Zero disables catching up, leaving them to their next run.
+//
+// In DryRun mode restake transactions are simulated and stored in the history instead of
+// being broadcast. Failed simulations neither count towards suspending a record nor are
+// added to its errors.
+//
+// The intervals of records with the AUTO frequency are recomputed every
+// AutoEvaluateInterval.
+type BotConfig struct {
+	Workers              int      `toml:"workers"`
+	RestakeTimeout       Duration `toml:"restake_timeout"`
+	Retries              int      `toml:"retries"`
+	RetryBackoff         Duration `toml:"retry_backoff"`
+	SuspendAfter         uint32   `toml:"suspend_after"`
+	CatchUpLimit         int      `toml:"catch_up_limit"`
+	DryRun               bool     `toml:"dry_run"`
+	AutoEvaluateInterval Duration `toml:"auto_evaluate_interval"`
+}
+
+func DefaultBotConfig() BotConfig {
+	return BotConfig{
+		Workers:              8,
+		RestakeTimeout:       Duration{2 * time.Minute},
+		Retries:              3,
+		RetryBackoff:         Duration{5 * time.Second},
+		SuspendAfter:         5,
+		CatchUpLimit:         10,
+		AutoEvaluateInterval: Duration{24 * time.Hour},
+	}
+}
+
+const (
+	StoreBackendBadger = "badger"
+	StoreBackendMemory = "memory"
+	StoreBackendSQL    = "sql"
+)
+
+// StoreConfig selects where records and history are persisted. The sql backend
+// supports the "sqlite3" and "postgres" drivers with the DSN passed as is to the driver.
+// The memory backend loses all state on shutdown and is intended for testing.
+//
+// The badger backend periodically garbage collects its value log every GCInterval,
+// rewriting files of which at least GCDiscardRatio can be discarded. A zero interval
+// disables garbage collection.
+type StoreConfig struct {
+	Backend        string   `toml:"backend"`
+	Driver         string   `toml:"driver"`
+	DSN            string   `toml:"dsn"`
+	GCInterval     Duration `toml:"gc_interval"`
+	GCDiscardRatio float64  `toml:"gc_discard_ratio"`
+}
+
+func DefaultStoreConfig() StoreConfig {
+	return StoreConfig{
+		Backend:        StoreBackendBadger,
+		GCInterval:     Duration{10 * time.Minute},
+		GCDiscardRatio: 0.5,
+	}
 }
 
 func DefaultChains() []Chain {
 	return []Chain{
 		{
-			GRPC:             "localhost:9090",
-			RPC:              "localhost:26657",
-			Id:               "cosmoshub-4",
-			Prefix:           "cosmos",
-			DefaultFrequency: int32(Frequency_DAILY),
-			DefaultTolerance: 1000000,
-			NativeDenom:      "uatom",
-			AppName:          "gaia",
-			RestakeFee:       5000,
+			GRPC:                  "localhost:9090",
+			RPC:                   "localhost:26657",
+			Id:                    "cosmoshub-4",
+			Prefix:                "cosmos",
+			DefaultFrequency:      int32(Frequency_DAILY),
+			DefaultTolerance:      1000000,
+			NativeDenom:           "uatom",
+			AppName:               "gaia",
+			RestakeFee:            5000,
+			MinInterval:           Duration{time.Hour},
+			Concurrency:           4,
+			Spread:                Duration{time.Hour},
+			MinRewardsFeeMultiple: 1,
 		},
 	}
 }
@@ -42,7 +122,8 @@ func (cfg Config) Save(file string) error {
 }
 
 func LoadConfig(file string) (Config, error) {
-	var config Config
+	// configs written by older versions lack the newer store settings
+	config := Config{Store: DefaultStoreConfig(), Bot: DefaultBotConfig()}
 	_, err := toml.DecodeFile(file, &config)
 	if err != nil {
 		return config, fmt.Errorf("failed to load config from %q: %w", file, err)
@@ -60,17 +141,96 @@ type Chain struct {
 	NativeDenom      string `toml:"native_denom"`
 	AppName          string `toml:"app_name"`
 	RestakeFee       int64  `toml:"restake_fee"`
+	// MinInterval is the shortest schedule records on this chain may register with
+	MinInterval Duration `toml:"min_interval"`
+	// Concurrency bounds the amount of records of this chain restaked at the same time.
+	// Zero leaves only the global limit of workers.
+	Concurrency int `toml:"concurrency"`
+	// Spread delays the runs of each record by a stable offset within the first Spread
+	// of their interval so that records sharing a schedule don't all run at once. Jitter
+	// adds a random delay on top. Zero disables either.
+	Spread Duration `toml:"spread"`
+	Jitter Duration `toml:"jitter"`
+	// MinRewards and MinRewardsFeeMultiple skip restakes whose rewards are below an
+	// absolute amount or below a multiple of the restake fee. Zero disables either.
+	MinRewards            int64   `toml:"min_rewards"`
+	MinRewardsFeeMultiple float64 `toml:"min_rewards_fee_multiple"`
+}
+
+// RewardThreshold returns the least amount of rewards worth restaking for the record.
+// The thresholds set on the record take precedence over those of the chain, including
+// zero which disables a threshold of the chain.
+func (c Chain) RewardThreshold(record *Record) int64 {
+	minRewards, multiple := c.MinRewards, c.MinRewardsFeeMultiple
+	if record.MinRewards != nil {
+		minRewards = *record.MinRewards
+	}
+	if record.MinRewardsFeeMultiple != nil {
+		multiple = *record.MinRewardsFeeMultiple
+	}
+	if feeThreshold := int64(math.Ceil(multiple * float64(c.RestakeFee))); feeThreshold > minRewards {
+		return feeThreshold
+	}
+	return minRewards
+}
+
+// OperatorAddress returns the address of the validator operated by an account address
+// of the chain
+func (c Chain) OperatorAddress(address string) (string, error) {
+	hrp, bz, err := bech32.DecodeAndConvert(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	if hrp != c.Prefix {
+		return "", fmt.Errorf("address %s does not belong to chain %s (prefix %s)", address, c.Id, c.Prefix)
+	}
+	return bech32.ConvertAndEncode(c.Prefix+"valoper", bz)
 }
 
 type ChainRegistry []Chain
 
+// FindChainFromAddress decodes a bech32 address, validating its checksum, and returns the
+// chain whose prefix exactly matches the human readable part. It errors if more than
+// one chain shares that prefix, in which case the chain must be selected by id.
 func (r ChainRegistry) FindChainFromAddress(address string) (Chain, error) {
+	hrp, _, err := bech32.DecodeAndConvert(address)
+	if err != nil {
+		return Chain{}, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	var matches []Chain
 	for _, chain := range r {
-		if strings.HasPrefix(address, chain.Prefix) {
-			return chain, nil
+		if hrp == chain.Prefix {
+			matches = append(matches, chain)
 		}
 	}
-	return Chain{}, fmt.Errorf("no chain found for address %s", address)
+	switch len(matches) {
+	case 0:
+		return Chain{}, fmt.Errorf("no chain found for address %s", address)
+	case 1:
+		return matches[0], nil
+	default:
+		return Chain{}, fmt.Errorf("address %s matches %d chains with prefix %s, a chain id must be specified", address, len(matches), hrp)
+	}
+}
+
+// FindChain returns the chain with the given id after checking that the address belongs
+// to it. If id is empty the chain is resolved from the address alone.
+func (r ChainRegistry) FindChain(id, address string) (Chain, error) {
+	if id == "" {
+		return r.FindChainFromAddress(address)
+	}
+	chain, err := r.FindChainById(id)
+	if err != nil {
+		return Chain{}, err
+	}
+	hrp, _, err := bech32.DecodeAndConvert(address)
+	if err != nil {
+		return Chain{}, fmt.Errorf("invalid address %s: %w", address, err)
+	}
+	if hrp != chain.Prefix {
+		return Chain{}, fmt.Errorf("address %s does not belong to chain %s (prefix %s)", address, chain.Id, chain.Prefix)
+	}
+	return chain, nil
 }
 
 func (r ChainRegistry) FindChainById(id string) (Chain, error) {
@@ -0,0 +1,99 @@
+package types_test
+
+import (
+	"testing"
+
+	"github.com/cosmos/cosmos-sdk/types/bech32"
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestFindChainFromAddress(t *testing.T) {
+	registry := types.ChainRegistry{
+		{Id: "cosmoshub-4", Prefix: "cosmos"},
+		{Id: "cosmosfoo-1", Prefix: "cosmosfoo"},
+		{Id: "osmosis-1", Prefix: "osmo"},
+		{Id: "osmo-test-4", Prefix: "osmo"},
+	}
+	address := func(prefix string) string {
+		addr, err := bech32.ConvertAndEncode(prefix, make([]byte, 20))
+		require.NoError(t, err)
+		return addr
+	}
+
+	chain, err := registry.FindChainFromAddress(address("cosmos"))
+	require.NoError(t, err)
+	require.Equal(t, "cosmoshub-4", chain.Id)
+
+	// a prefix that starts with the prefix of another chain
+	chain, err = registry.FindChainFromAddress(address("cosmosfoo"))
+	require.NoError(t, err)
+	require.Equal(t, "cosmosfoo-1", chain.Id)
+
+	_, err = registry.FindChainFromAddress(address("juno"))
+	require.Error(t, err)
+
+	// invalid checksum
+	invalid := address("cosmos")
+	invalid = invalid[:len(invalid)-1] + "x"
+	_, err = registry.FindChainFromAddress(invalid)
+	require.Error(t, err)
+
+	// chains sharing a prefix must be selected by id
+	_, err = registry.FindChainFromAddress(address("osmo"))
+	require.Error(t, err)
+	chain, err = registry.FindChain("osmo-test-4", address("osmo"))
+	require.NoError(t, err)
+	require.Equal(t, "osmo-test-4", chain.Id)
+
+	_, err = registry.FindChain("cosmoshub-4", address("osmo"))
+	require.Error(t, err)
+}
+
+func TestRewardThreshold(t *testing.T) {
+	chain := types.Chain{RestakeFee: 5000}
+	require.Zero(t, chain.RewardThreshold(&types.Record{}))
+
+	chain.MinRewardsFeeMultiple = 1.5
+	require.EqualValues(t, 7500, chain.RewardThreshold(&types.Record{}))
+
+	// the higher of both thresholds applies
+	chain.MinRewards = 10000
+	require.EqualValues(t, 10000, chain.RewardThreshold(&types.Record{}))
+	chain.MinRewards = 1000
+	require.EqualValues(t, 7500, chain.RewardThreshold(&types.Record{}))
+
+	// records override the thresholds of the chain
+	minRewards := func(amount int64) *int64 { return &amount }
+	multiple := func(multiple float64) *float64 { return &multiple }
+	require.EqualValues(t, 20000, chain.RewardThreshold(&types.Record{MinRewards: minRewards(20000)}))
+	require.EqualValues(t, 2500, chain.RewardThreshold(&types.Record{MinRewardsFeeMultiple: multiple(0.5)}))
+	require.EqualValues(t, 15000, chain.RewardThreshold(&types.Record{MinRewards: minRewards(500), MinRewardsFeeMultiple: multiple(3)}))
+
+	// an explicit zero opts out of the threshold of the chain
+	require.EqualValues(t, 1000, chain.RewardThreshold(&types.Record{MinRewardsFeeMultiple: multiple(0)}))
+	require.EqualValues(t, 7500, chain.RewardThreshold(&types.Record{MinRewards: minRewards(0)}))
+	require.Zero(t, chain.RewardThreshold(&types.Record{MinRewards: minRewards(0), MinRewardsFeeMultiple: multiple(0)}))
+}
+
+func TestOperatorAddress(t *testing.T) {
+	chain := types.DefaultChains()[0]
+	bz := []byte("operator address bytes")[:20]
+	address, err := bech32.ConvertAndEncode("cosmos", bz)
+	require.NoError(t, err)
+	expected, err := bech32.ConvertAndEncode("cosmosvaloper", bz)
+	require.NoError(t, err)
+
+	operator, err := chain.OperatorAddress(address)
+	require.NoError(t, err)
+	require.Equal(t, expected, operator)
+
+	// addresses of other chains and validator addresses are rejected
+	osmo, err := bech32.ConvertAndEncode("osmo", bz)
+	require.NoError(t, err)
+	_, err = chain.OperatorAddress(osmo)
+	require.Error(t, err)
+	_, err = chain.OperatorAddress(operator)
+	require.Error(t, err)
+}
@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// Duration is a time.Duration that is written to and read from the config as a string
+// such as "10m" or "1h30m".
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
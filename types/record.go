@@ -0,0 +1,106 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// MaxErrorEntries is the number of failed attempts kept per record
+const MaxErrorEntries = 10
+
+// AddError appends a failed attempt to the errors of the record, dropping the oldest
+// entries beyond MaxErrorEntries.
+func (r *Record) AddError(entry *ErrorEntry) {
+	r.Errors = append(r.Errors, entry)
+	if len(r.Errors) > MaxErrorEntries {
+		r.Errors = append([]*ErrorEntry(nil), r.Errors[len(r.Errors)-MaxErrorEntries:]...)
+	}
+}
+
+// AddFailure records a failed restake and counts it as a consecutive failure. The
+// record is suspended once it fails suspendAfter times in a row, unless suspendAfter is
+// zero. It reports whether this failure suspended the record.
+func (r *Record) AddFailure(entry *ErrorEntry, suspendAfter uint32) bool {
+	r.AddError(entry)
+	r.ConsecutiveFailures++
+	if suspendAfter == 0 || r.Suspended || r.ConsecutiveFailures < suspendAfter {
+		return false
+	}
+	r.Suspended = true
+	return true
+}
+
+// Unsuspend resumes scheduling a suspended record and resets its consecutive failures
+func (r *Record) Unsuspend() {
+	r.Suspended = false
+	r.ConsecutiveFailures = 0
+}
+
+// SetAutoInterval sets the interval computed for a record with the AUTO frequency
+func (r *Record) SetAutoInterval(interval time.Duration, now time.Time) {
+	r.AutoIntervalSeconds = int64(interval / time.Second)
+	r.AutoEvaluatedUnixTime = now.Unix()
+}
+
+// LastError returns the most recent failed attempt or nil if there is none
+func (r *Record) LastError() *ErrorEntry {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors[len(r.Errors)-1]
+}
+
+// Failing reports whether the last restake attempt of the record failed, that is, it
+// has not been restaked successfully since its last error.
+func (r *Record) Failing() bool {
+	last := r.LastError()
+	return last != nil && last.UnixTime >= r.LastUpdatedUnixTime
+}
+
+// AddRestake adds the amounts of a successful restake to the cumulative amounts of each
+// validator
+func (r *Record) AddRestake(validators []*ValidatorRestake) {
+	for _, v := range validators {
+		idx := sort.Search(len(r.Validators), func(i int) bool {
+			return r.Validators[i].ValidatorAddress >= v.ValidatorAddress
+		})
+		if idx < len(r.Validators) && r.Validators[idx].ValidatorAddress == v.ValidatorAddress {
+			r.Validators[idx].Claimed += v.Claimed
+			r.Validators[idx].Delegated += v.Delegated
+			continue
+		}
+		r.Validators = append(r.Validators, nil)
+		copy(r.Validators[idx+1:], r.Validators[idx:])
+		r.Validators[idx] = &ValidatorRestake{
+			ValidatorAddress: v.ValidatorAddress,
+			Claimed:          v.Claimed,
+			Delegated:        v.Delegated,
+		}
+	}
+}
+
+// MarshalJSON writes error codes by name so that they are readable in API responses
+func (c ErrorCode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON accepts error codes by name or number
+func (c *ErrorCode) UnmarshalJSON(bz []byte) error {
+	var name string
+	if err := json.Unmarshal(bz, &name); err != nil {
+		var value int32
+		if err := json.Unmarshal(bz, &value); err != nil {
+			return err
+		}
+		*c = ErrorCode(value)
+		return nil
+	}
+	value, ok := ErrorCode_value[name]
+	if !ok {
+		return fmt.Errorf("unknown error code %q", name)
+	}
+	*c = ErrorCode(value)
+	return nil
+}
@@ -0,0 +1,87 @@
+package types_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestRecordErrors(t *testing.T) {
+	record := &types.Record{}
+	require.Nil(t, record.LastError())
+	require.False(t, record.Failing())
+
+	for i := 1; i <= types.MaxErrorEntries+5; i++ {
+		record.AddError(&types.ErrorEntry{UnixTime: int64(i), Code: types.ErrorCode_NODE_UNREACHABLE})
+	}
+	require.Len(t, record.Errors, types.MaxErrorEntries)
+	require.EqualValues(t, 6, record.Errors[0].UnixTime)
+	require.EqualValues(t, types.MaxErrorEntries+5, record.LastError().UnixTime)
+	require.True(t, record.Failing())
+
+	// a later successful restake clears the failing state but keeps the history
+	record.LastUpdatedUnixTime = types.MaxErrorEntries + 6
+	require.False(t, record.Failing())
+	require.Len(t, record.Errors, types.MaxErrorEntries)
+}
+
+func TestRecordSuspension(t *testing.T) {
+	record := &types.Record{}
+	for i := 1; i < 3; i++ {
+		require.False(t, record.AddFailure(&types.ErrorEntry{UnixTime: int64(i)}, 3))
+	}
+	require.False(t, record.Suspended)
+	require.True(t, record.AddFailure(&types.ErrorEntry{UnixTime: 3}, 3))
+	require.True(t, record.Suspended)
+	// further failures don't suspend the record again
+	require.False(t, record.AddFailure(&types.ErrorEntry{UnixTime: 4}, 3))
+	require.EqualValues(t, 4, record.ConsecutiveFailures)
+
+	record.Unsuspend()
+	require.False(t, record.Suspended)
+	require.Zero(t, record.ConsecutiveFailures)
+	require.Len(t, record.Errors, 4)
+
+	// a zero threshold never suspends
+	for i := 0; i < 10; i++ {
+		require.False(t, record.AddFailure(&types.ErrorEntry{}, 0))
+	}
+	require.False(t, record.Suspended)
+}
+
+func TestErrorCodeJSON(t *testing.T) {
+	entry := &types.ErrorEntry{UnixTime: 1, Code: types.ErrorCode_GRANT_MISSING}
+	bz, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.Contains(t, string(bz), `"code":"GRANT_MISSING"`)
+
+	decoded := &types.ErrorEntry{}
+	require.NoError(t, json.Unmarshal(bz, decoded))
+	require.Equal(t, entry.Code, decoded.Code)
+	require.NoError(t, json.Unmarshal([]byte(`{"code":4}`), decoded))
+	require.Equal(t, types.ErrorCode_NODE_UNREACHABLE, decoded.Code)
+	require.Error(t, json.Unmarshal([]byte(`{"code":"BOGUS"}`), decoded))
+}
+
+func TestRecordAddRestake(t *testing.T) {
+	record := &types.Record{}
+	record.AddRestake([]*types.ValidatorRestake{
+		{ValidatorAddress: "valoper2", Claimed: 10, Delegated: 8},
+		{ValidatorAddress: "valoper1", Claimed: 5, Delegated: 4},
+	})
+	restake := []*types.ValidatorRestake{
+		{ValidatorAddress: "valoper3", Claimed: 1, Delegated: 1},
+		{ValidatorAddress: "valoper1", Claimed: 5, Delegated: 5},
+	}
+	record.AddRestake(restake)
+
+	require.Len(t, record.Validators, 3)
+	require.Equal(t, &types.ValidatorRestake{ValidatorAddress: "valoper1", Claimed: 10, Delegated: 9}, record.Validators[0])
+	require.Equal(t, &types.ValidatorRestake{ValidatorAddress: "valoper2", Claimed: 10, Delegated: 8}, record.Validators[1])
+	require.Equal(t, &types.ValidatorRestake{ValidatorAddress: "valoper3", Claimed: 1, Delegated: 1}, record.Validators[2])
+	// the amounts of the restake are copied, not shared
+	require.EqualValues(t, 5, restake[1].Claimed)
+}
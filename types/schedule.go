@@ -0,0 +1,165 @@
+package types
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+)
+
+// frequencySchedules are the schedules of records that don't set one of their own
+var frequencySchedules = map[Frequency]string{
+	Frequency_HOURLY:     "@hourly",
+	Frequency_QUARTERDAY: "@every 6h",
+	Frequency_DAILY:      "@daily",
+	Frequency_WEEKLY:     "@weekly",
+	Frequency_MONTHLY:    "@monthly",
+	// until the bot computed an interval
+	Frequency_AUTO: "@daily",
+}
+
+// ScheduleSpec returns the schedule of the record, falling back to the schedule of its
+// frequency or, for the AUTO frequency, the computed interval. It is empty if the record
+// has neither.
+func (r *Record) ScheduleSpec() string {
+	if r.Schedule != "" {
+		return r.Schedule
+	}
+	if r.Frequency == Frequency_AUTO && r.AutoIntervalSeconds > 0 {
+		return fmt.Sprintf("@every %s", time.Duration(r.AutoIntervalSeconds)*time.Second)
+	}
+	return frequencySchedules[r.Frequency]
+}
+
+// ParseSchedule parses a standard five field cron expression such as "0 14 */3 * *", a
+// descriptor such as "@daily" or "@every 72h", or a plain interval such as "72h".
+// Cron expressions are evaluated in UTC unless prefixed with CRON_TZ.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	if interval, err := time.ParseDuration(spec); err == nil {
+		if interval < time.Second {
+			return nil, fmt.Errorf("interval %s is shorter than a second", interval)
+		}
+		return cron.Every(interval), nil
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+	}
+	return schedule, nil
+}
+
+// scheduleSamples bounds the amount of runs inspected by MinInterval
+const scheduleSamples = 1000
+
+// MinInterval returns the shortest time between two consecutive runs of the schedule
+// within the next year, or within its next thousand runs if those come sooner.
+func MinInterval(schedule cron.Schedule) time.Duration {
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return every.Delay
+	}
+	start := time.Now().UTC()
+	prev := schedule.Next(start)
+	if prev.IsZero() {
+		return 0
+	}
+	horizon := start.AddDate(1, 0, 0)
+	var min time.Duration
+	for i := 0; i < scheduleSamples && prev.Before(horizon); i++ {
+		next := schedule.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(prev); min == 0 || gap < min {
+			min = gap
+		}
+		prev = next
+	}
+	return min
+}
+
+// ValidateSchedule checks that the schedule of a record parses and that it does not run
+// more often than the minimum interval of its chain.
+func (c Chain) ValidateSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return nil, err
+	}
+	if c.MinInterval.Duration > 0 {
+		if interval := MinInterval(schedule); interval < c.MinInterval.Duration {
+			return nil, fmt.Errorf("schedule %q runs every %s which is more often than the minimum interval of %s on %s", spec, interval, c.MinInterval.Duration, c.Id)
+		}
+	}
+	return schedule, nil
+}
+
+// Schedule returns the schedule of a record on this chain. If the chain spreads its
+// records, every run is delayed by a stable offset derived from the address of the
+// record within the first Spread of the interval, followed by up to Jitter of random
+// delay. Neither ever delays a run past the next one.
+func (c Chain) Schedule(record *Record) (cron.Schedule, error) {
+	schedule, err := ParseSchedule(record.ScheduleSpec())
+	if err != nil {
+		return nil, err
+	}
+	if c.Spread.Duration <= 0 && c.Jitter.Duration <= 0 {
+		return schedule, nil
+	}
+	interval := MinInterval(schedule)
+	if interval <= 0 {
+		return schedule, nil
+	}
+	window := c.Spread.Duration
+	if window > interval {
+		window = interval
+	}
+	offset := AddressOffset(record.Address, window)
+	jitter := c.Jitter.Duration
+	if jitter > interval-offset {
+		jitter = interval - offset
+	}
+	return Offset(schedule, offset, jitter), nil
+}
+
+// AddressOffset returns an offset in [0, window) derived from the address. It is the
+// same for an address across restarts and evenly spread across addresses.
+func AddressOffset(address string, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(address))
+	return time.Duration(h.Sum64() % uint64(window))
+}
+
+// offsetSchedule runs offset after every run of the underlying schedule, plus a random
+// delay of up to jitter
+type offsetSchedule struct {
+	schedule cron.Schedule
+	offset   time.Duration
+	jitter   time.Duration
+}
+
+// Offset delays every run of the schedule by offset and a random delay below jitter.
+// The jitter must leave the run before the next run of the underlying schedule.
+func Offset(schedule cron.Schedule, offset, jitter time.Duration) cron.Schedule {
+	return offsetSchedule{schedule: schedule, offset: offset, jitter: jitter}
+}
+
+func (s offsetSchedule) Next(t time.Time) time.Time {
+	next := s.schedule.Next(t.Add(-s.offset))
+	if next.IsZero() {
+		return next
+	}
+	next = next.Add(s.offset)
+	if s.jitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(s.jitter))))
+	}
+	return next
+}
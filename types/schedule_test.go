@@ -0,0 +1,125 @@
+package types_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/plural-labs/stakebot/types"
+)
+
+func TestParseSchedule(t *testing.T) {
+	testCases := []struct {
+		spec     string
+		interval time.Duration
+		valid    bool
+	}{
+		{"@hourly", time.Hour, true},
+		{"@every 6h", 6 * time.Hour, true},
+		{"72h", 72 * time.Hour, true},
+		{"0 14 */3 * *", 24 * time.Hour, true}, // across the end of a month
+		{"CRON_TZ=Asia/Tokyo 30 9 * * MON", 7 * 24 * time.Hour, true},
+		{"*/15 * * * *", 15 * time.Minute, true},
+		{"", 0, false},
+		{"100ms", 0, false},
+		{"every day", 0, false},
+		{"0 14 * *", 0, false},
+	}
+	for _, tc := range testCases {
+		schedule, err := types.ParseSchedule(tc.spec)
+		if !tc.valid {
+			require.Error(t, err, tc.spec)
+			continue
+		}
+		require.NoError(t, err, tc.spec)
+		require.Equal(t, tc.interval, types.MinInterval(schedule), tc.spec)
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	chain := types.DefaultChains()[0]
+	chain.MinInterval = types.Duration{Duration: 6 * time.Hour}
+
+	_, err := chain.ValidateSchedule("@daily")
+	require.NoError(t, err)
+	_, err = chain.ValidateSchedule("@every 6h")
+	require.NoError(t, err)
+	_, err = chain.ValidateSchedule("@hourly")
+	require.Error(t, err)
+	_, err = chain.ValidateSchedule("0 */2 * * *")
+	require.Error(t, err)
+
+	chain.MinInterval = types.Duration{}
+	_, err = chain.ValidateSchedule("@hourly")
+	require.NoError(t, err)
+}
+
+func TestScheduleSpec(t *testing.T) {
+	record := &types.Record{Frequency: types.Frequency_WEEKLY}
+	require.Equal(t, "@weekly", record.ScheduleSpec())
+	record.Schedule = "0 14 */3 * *"
+	require.Equal(t, "0 14 */3 * *", record.ScheduleSpec())
+	require.Empty(t, (&types.Record{}).ScheduleSpec())
+
+	// AUTO records run daily until their interval has been computed
+	record = &types.Record{Frequency: types.Frequency_AUTO}
+	require.Equal(t, "@daily", record.ScheduleSpec())
+	record.SetAutoInterval(193*time.Hour, time.Unix(1000, 0))
+	require.Equal(t, "@every 193h0m0s", record.ScheduleSpec())
+	require.EqualValues(t, 1000, record.AutoEvaluatedUnixTime)
+	_, err := types.ParseSchedule(record.ScheduleSpec())
+	require.NoError(t, err)
+}
+
+func TestChainSchedule(t *testing.T) {
+	record := &types.Record{Address: "cosmos1vhpsuaxg51gvvzwyhqejvwfved5ywa3n6vl4ld", Frequency: types.Frequency_DAILY}
+	midnight := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// without spreading records run at the exact time of their schedule
+	schedule, err := types.Chain{}.Schedule(record)
+	require.NoError(t, err)
+	require.Equal(t, midnight.Add(24*time.Hour), schedule.Next(midnight))
+
+	// spread records run at a stable offset within the window
+	chain := types.Chain{Spread: types.Duration{Duration: time.Hour}}
+	offset := types.AddressOffset(record.Address, time.Hour)
+	require.Less(t, offset, time.Hour)
+	require.Equal(t, offset, types.AddressOffset(record.Address, time.Hour))
+	schedule, err = chain.Schedule(record)
+	require.NoError(t, err)
+	next := schedule.Next(midnight.Add(-time.Minute))
+	require.Equal(t, midnight.Add(offset), next)
+	require.Equal(t, midnight.Add(24*time.Hour+offset), schedule.Next(next))
+
+	// the window is capped by the interval of the schedule
+	hourly := &types.Record{Address: record.Address, Frequency: types.Frequency_HOURLY}
+	chain.Spread = types.Duration{Duration: 24 * time.Hour}
+	schedule, err = chain.Schedule(hourly)
+	require.NoError(t, err)
+	next = schedule.Next(midnight)
+	require.Equal(t, midnight.Add(types.AddressOffset(hourly.Address, time.Hour)), next)
+
+	// jitter never delays a run past the next one
+	chain = types.Chain{Spread: types.Duration{Duration: time.Hour}, Jitter: types.Duration{Duration: 48 * time.Hour}}
+	schedule, err = chain.Schedule(record)
+	require.NoError(t, err)
+	prev := midnight
+	for i := 0; i < 100; i++ {
+		next := schedule.Next(prev)
+		require.True(t, next.After(prev))
+		require.Less(t, next.Sub(prev), 48*time.Hour)
+		prev = next
+	}
+
+	// offsets are spread across addresses
+	buckets := make(map[time.Duration]int)
+	for i := 0; i < 1000; i++ {
+		buckets[types.AddressOffset(fmt.Sprintf("address%d", i), time.Hour)/(6*time.Minute)]++
+	}
+	require.Len(t, buckets, 10)
+	for _, n := range buckets {
+		require.InDelta(t, 100, n, 40)
+	}
+}
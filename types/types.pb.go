@@ -29,6 +29,8 @@ const (
 	Frequency_DAILY      Frequency = 3
 	Frequency_WEEKLY     Frequency = 4
 	Frequency_MONTHLY    Frequency = 5
+	// the interval is computed by the bot to maximize the yield after fees
+	Frequency_AUTO Frequency = 6
 )
 
 // Enum value maps for Frequency.
@@ -40,6 +42,7 @@ var (
 		3: "DAILY",
 		4: "WEEKLY",
 		5: "MONTHLY",
+		6: "AUTO",
 	}
 	Frequency_value = map[string]int32{
 		"UNKNOWN":    0,
@@ -48,6 +51,7 @@ var (
 		"DAILY":      3,
 		"WEEKLY":     4,
 		"MONTHLY":    5,
+		"AUTO":       6,
 	}
 )
 
@@ -78,36 +82,1059 @@ func (Frequency) EnumDescriptor() ([]byte, []int) {
 	return file_types_proto_rawDescGZIP(), []int{0}
 }
 
+type Outcome int32
+
+const (
+	Outcome_NONE       Outcome = 0
+	Outcome_SUCCESS    Outcome = 1
+	Outcome_FAILURE    Outcome = 2
+	Outcome_NO_REWARDS Outcome = 3
+	// the restake transaction was only simulated in dry run mode
+	Outcome_SIMULATED Outcome = 4
+	// the restake was not attempted, see the skip reason of the event
+	Outcome_SKIPPED Outcome = 5
+)
+
+// Enum value maps for Outcome.
+var (
+	Outcome_name = map[int32]string{
+		0: "NONE",
+		1: "SUCCESS",
+		2: "FAILURE",
+		3: "NO_REWARDS",
+		4: "SIMULATED",
+		5: "SKIPPED",
+	}
+	Outcome_value = map[string]int32{
+		"NONE":       0,
+		"SUCCESS":    1,
+		"FAILURE":    2,
+		"NO_REWARDS": 3,
+		"SIMULATED":  4,
+		"SKIPPED":    5,
+	}
+)
+
+func (x Outcome) Enum() *Outcome {
+	p := new(Outcome)
+	*p = x
+	return p
+}
+
+func (x Outcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Outcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[1].Descriptor()
+}
+
+func (Outcome) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[1]
+}
+
+func (x Outcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Outcome.Descriptor instead.
+func (Outcome) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{1}
+}
+
+// RecordType is the kind of address that is restaked
+type RecordType int32
+
+const (
+	RecordType_DELEGATOR RecordType = 0
+	// the address operates a validator whose commission is restaked along with its rewards
+	RecordType_VALIDATOR_OPERATOR RecordType = 1
+)
+
+// Enum value maps for RecordType.
+var (
+	RecordType_name = map[int32]string{
+		0: "DELEGATOR",
+		1: "VALIDATOR_OPERATOR",
+	}
+	RecordType_value = map[string]int32{
+		"DELEGATOR":          0,
+		"VALIDATOR_OPERATOR": 1,
+	}
+)
+
+func (x RecordType) Enum() *RecordType {
+	p := new(RecordType)
+	*p = x
+	return p
+}
+
+func (x RecordType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RecordType) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[2].Descriptor()
+}
+
+func (RecordType) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[2]
+}
+
+func (x RecordType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RecordType.Descriptor instead.
+func (RecordType) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{2}
+}
+
+// AllocationStrategy selects how the restaked balance is split between validators
+type AllocationStrategy int32
+
+const (
+	// in proportion to the rewards claimed from each validator
+	AllocationStrategy_PROPORTIONAL_TO_REWARDS AllocationStrategy = 0
+	// in proportion to the amount delegated to each validator
+	AllocationStrategy_PROPORTIONAL_TO_DELEGATION AllocationStrategy = 1
+	// equally between the validators the rewards are claimed from
+	AllocationStrategy_EQUAL_SPLIT AllocationStrategy = 2
+	// all to a single validator
+	AllocationStrategy_SINGLE_VALIDATOR AllocationStrategy = 3
+	// in proportion to weights chosen by the delegator
+	AllocationStrategy_TARGET_WEIGHTS AllocationStrategy = 4
+)
+
+// Enum value maps for AllocationStrategy.
+var (
+	AllocationStrategy_name = map[int32]string{
+		0: "PROPORTIONAL_TO_REWARDS",
+		1: "PROPORTIONAL_TO_DELEGATION",
+		2: "EQUAL_SPLIT",
+		3: "SINGLE_VALIDATOR",
+		4: "TARGET_WEIGHTS",
+	}
+	AllocationStrategy_value = map[string]int32{
+		"PROPORTIONAL_TO_REWARDS":    0,
+		"PROPORTIONAL_TO_DELEGATION": 1,
+		"EQUAL_SPLIT":                2,
+		"SINGLE_VALIDATOR":           3,
+		"TARGET_WEIGHTS":             4,
+	}
+)
+
+func (x AllocationStrategy) Enum() *AllocationStrategy {
+	p := new(AllocationStrategy)
+	*p = x
+	return p
+}
+
+func (x AllocationStrategy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AllocationStrategy) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[3].Descriptor()
+}
+
+func (AllocationStrategy) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[3]
+}
+
+func (x AllocationStrategy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AllocationStrategy.Descriptor instead.
+func (AllocationStrategy) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{3}
+}
+
+// UnhealthyPolicy selects what happens to the share of unhealthy validators
+type UnhealthyPolicy int32
+
+const (
+	// the healthy validators receive it in proportion to their own share
+	UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY UnhealthyPolicy = 0
+	// the healthy validators receive equal parts of it
+	UnhealthyPolicy_REDISTRIBUTE_EQUALLY UnhealthyPolicy = 1
+	// it stays in the balance of the delegator
+	UnhealthyPolicy_KEEP_UNDELEGATED UnhealthyPolicy = 2
+)
+
+// Enum value maps for UnhealthyPolicy.
+var (
+	UnhealthyPolicy_name = map[int32]string{
+		0: "REDISTRIBUTE_PROPORTIONALLY",
+		1: "REDISTRIBUTE_EQUALLY",
+		2: "KEEP_UNDELEGATED",
+	}
+	UnhealthyPolicy_value = map[string]int32{
+		"REDISTRIBUTE_PROPORTIONALLY": 0,
+		"REDISTRIBUTE_EQUALLY":        1,
+		"KEEP_UNDELEGATED":            2,
+	}
+)
+
+func (x UnhealthyPolicy) Enum() *UnhealthyPolicy {
+	p := new(UnhealthyPolicy)
+	*p = x
+	return p
+}
+
+func (x UnhealthyPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (UnhealthyPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[4].Descriptor()
+}
+
+func (UnhealthyPolicy) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[4]
+}
+
+func (x UnhealthyPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use UnhealthyPolicy.Descriptor instead.
+func (UnhealthyPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{4}
+}
+
+// ValidatorHealth is why a validator is not delegated to
+type ValidatorHealth int32
+
+const (
+	ValidatorHealth_HEALTHY ValidatorHealth = 0
+	ValidatorHealth_JAILED  ValidatorHealth = 1
+	// the validator double signed and is jailed forever
+	ValidatorHealth_TOMBSTONED ValidatorHealth = 2
+	ValidatorHealth_UNBONDING  ValidatorHealth = 3
+	ValidatorHealth_UNBONDED   ValidatorHealth = 4
+)
+
+// Enum value maps for ValidatorHealth.
+var (
+	ValidatorHealth_name = map[int32]string{
+		0: "HEALTHY",
+		1: "JAILED",
+		2: "TOMBSTONED",
+		3: "UNBONDING",
+		4: "UNBONDED",
+	}
+	ValidatorHealth_value = map[string]int32{
+		"HEALTHY":    0,
+		"JAILED":     1,
+		"TOMBSTONED": 2,
+		"UNBONDING":  3,
+		"UNBONDED":   4,
+	}
+)
+
+func (x ValidatorHealth) Enum() *ValidatorHealth {
+	p := new(ValidatorHealth)
+	*p = x
+	return p
+}
+
+func (x ValidatorHealth) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ValidatorHealth) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[5].Descriptor()
+}
+
+func (ValidatorHealth) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[5]
+}
+
+func (x ValidatorHealth) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ValidatorHealth.Descriptor instead.
+func (ValidatorHealth) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{5}
+}
+
+// SkipReason explains why a restake was skipped
+type SkipReason int32
+
+const (
+	SkipReason_NOT_SKIPPED SkipReason = 0
+	// the rewards were below the minimum rewards threshold of the record or chain
+	SkipReason_BELOW_THRESHOLD SkipReason = 1
+)
+
+// Enum value maps for SkipReason.
+var (
+	SkipReason_name = map[int32]string{
+		0: "NOT_SKIPPED",
+		1: "BELOW_THRESHOLD",
+	}
+	SkipReason_value = map[string]int32{
+		"NOT_SKIPPED":     0,
+		"BELOW_THRESHOLD": 1,
+	}
+)
+
+func (x SkipReason) Enum() *SkipReason {
+	p := new(SkipReason)
+	*p = x
+	return p
+}
+
+func (x SkipReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SkipReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[6].Descriptor()
+}
+
+func (SkipReason) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[6]
+}
+
+func (x SkipReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SkipReason.Descriptor instead.
+func (SkipReason) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{6}
+}
+
+// ErrorCode classifies why a restake attempt failed
+type ErrorCode int32
+
+const (
+	ErrorCode_UNCLASSIFIED ErrorCode = 0
+	// the address has not granted, or has revoked, the authz grants of the stakebot
+	ErrorCode_GRANT_MISSING ErrorCode = 1
+	// the fee grant is missing, expired or its spend limit is used up
+	ErrorCode_FEEGRANT_EXHAUSTED ErrorCode = 2
+	ErrorCode_INSUFFICIENT_FEE   ErrorCode = 3
+	ErrorCode_NODE_UNREACHABLE   ErrorCode = 4
+	ErrorCode_SEQUENCE_MISMATCH  ErrorCode = 5
+	// the restake did not complete within the restake timeout
+	ErrorCode_TIMEOUT ErrorCode = 6
+)
+
+// Enum value maps for ErrorCode.
+var (
+	ErrorCode_name = map[int32]string{
+		0: "UNCLASSIFIED",
+		1: "GRANT_MISSING",
+		2: "FEEGRANT_EXHAUSTED",
+		3: "INSUFFICIENT_FEE",
+		4: "NODE_UNREACHABLE",
+		5: "SEQUENCE_MISMATCH",
+		6: "TIMEOUT",
+	}
+	ErrorCode_value = map[string]int32{
+		"UNCLASSIFIED":       0,
+		"GRANT_MISSING":      1,
+		"FEEGRANT_EXHAUSTED": 2,
+		"INSUFFICIENT_FEE":   3,
+		"NODE_UNREACHABLE":   4,
+		"SEQUENCE_MISMATCH":  5,
+		"TIMEOUT":            6,
+	}
+)
+
+func (x ErrorCode) Enum() *ErrorCode {
+	p := new(ErrorCode)
+	*p = x
+	return p
+}
+
+func (x ErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_types_proto_enumTypes[7].Descriptor()
+}
+
+func (ErrorCode) Type() protoreflect.EnumType {
+	return &file_types_proto_enumTypes[7]
+}
+
+func (x ErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ErrorCode.Descriptor instead.
+func (ErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{7}
+}
+
 type Record struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Address                string    `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
-	Frequency              Frequency `protobuf:"varint,2,opt,name=frequency,proto3,enum=Frequency" json:"frequency,omitempty"`
-	Tolerance              int64     `protobuf:"varint,3,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
-	LastUpdatedUnixTime    int64     `protobuf:"varint,4,opt,name=last_updated_unix_time,json=lastUpdatedUnixTime,proto3" json:"last_updated_unix_time,omitempty"`
-	TotalAutostakedRewards int64     `protobuf:"varint,5,opt,name=total_autostaked_rewards,json=totalAutostakedRewards,proto3" json:"total_autostaked_rewards,omitempty"`
-	ErrorLogs              string    `protobuf:"bytes,6,opt,name=error_logs,json=errorLogs,proto3" json:"error_logs,omitempty"`
+	Address                string    `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Frequency              Frequency `protobuf:"varint,2,opt,name=frequency,proto3,enum=Frequency" json:"frequency,omitempty"`
+	Tolerance              int64     `protobuf:"varint,3,opt,name=tolerance,proto3" json:"tolerance,omitempty"`
+	LastUpdatedUnixTime    int64     `protobuf:"varint,4,opt,name=last_updated_unix_time,json=lastUpdatedUnixTime,proto3" json:"last_updated_unix_time,omitempty"`
+	TotalAutostakedRewards int64     `protobuf:"varint,5,opt,name=total_autostaked_rewards,json=totalAutostakedRewards,proto3" json:"total_autostaked_rewards,omitempty"`
+	// error_logs has been replaced by errors and is only read when migrating
+	//
+	// Deprecated: Do not use.
+	ErrorLogs string `protobuf:"bytes,6,opt,name=error_logs,json=errorLogs,proto3" json:"error_logs,omitempty"`
+	ChainId   string `protobuf:"bytes,7,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	// errors holds the most recent failed restake attempts, oldest first
+	Errors []*ErrorEntry `protobuf:"bytes,8,rep,name=errors,proto3" json:"errors,omitempty"`
+	// validators holds the cumulative amounts claimed from and delegated to each
+	// validator, ordered by validator address
+	Validators []*ValidatorRestake `protobuf:"bytes,9,rep,name=validators,proto3" json:"validators,omitempty"`
+	// schedule is a cron expression, descriptor or interval that replaces the frequency
+	// when set
+	Schedule string `protobuf:"bytes,10,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	// consecutive_failures counts the failed restakes since the last successful one
+	ConsecutiveFailures uint32 `protobuf:"varint,11,opt,name=consecutive_failures,json=consecutiveFailures,proto3" json:"consecutive_failures,omitempty"`
+	// suspended records are skipped by the scheduler until they are unsuspended
+	Suspended bool `protobuf:"varint,12,opt,name=suspended,proto3" json:"suspended,omitempty"`
+	// last_run summarizes the most recent restake attempt
+	LastRun *LastRun `protobuf:"bytes,13,opt,name=last_run,json=lastRun,proto3" json:"last_run,omitempty"`
+	// paused records are skipped by the scheduler until they are resumed
+	Paused bool `protobuf:"varint,14,opt,name=paused,proto3" json:"paused,omitempty"`
+	// min_rewards and min_rewards_fee_multiple override the thresholds of the chain when
+	// set, zero disabling the threshold. Restakes of rewards below either threshold are
+	// skipped.
+	MinRewards            *int64   `protobuf:"varint,15,opt,name=min_rewards,json=minRewards,proto3,oneof" json:"min_rewards,omitempty"`
+	MinRewardsFeeMultiple *float64 `protobuf:"fixed64,16,opt,name=min_rewards_fee_multiple,json=minRewardsFeeMultiple,proto3,oneof" json:"min_rewards_fee_multiple,omitempty"`
+	// auto_interval_seconds is the restake interval computed for the AUTO frequency at
+	// auto_evaluated_unix_time
+	AutoIntervalSeconds   int64 `protobuf:"varint,17,opt,name=auto_interval_seconds,json=autoIntervalSeconds,proto3" json:"auto_interval_seconds,omitempty"`
+	AutoEvaluatedUnixTime int64 `protobuf:"varint,18,opt,name=auto_evaluated_unix_time,json=autoEvaluatedUnixTime,proto3" json:"auto_evaluated_unix_time,omitempty"`
+	// allocation selects how the restaked balance is split between validators.
+	// allocation_validator is the target of SINGLE_VALIDATOR and allocation_weights
+	// the targets of TARGET_WEIGHTS.
+	Allocation          AllocationStrategy `protobuf:"varint,19,opt,name=allocation,proto3,enum=AllocationStrategy" json:"allocation,omitempty"`
+	AllocationValidator string             `protobuf:"bytes,20,opt,name=allocation_validator,json=allocationValidator,proto3" json:"allocation_validator,omitempty"`
+	AllocationWeights   []*ValidatorWeight `protobuf:"bytes,21,rep,name=allocation_weights,json=allocationWeights,proto3" json:"allocation_weights,omitempty"`
+	// unhealthy_policy selects what happens to the share of validators that are jailed,
+	// tombstoned or not bonded
+	UnhealthyPolicy UnhealthyPolicy `protobuf:"varint,22,opt,name=unhealthy_policy,json=unhealthyPolicy,proto3,enum=UnhealthyPolicy" json:"unhealthy_policy,omitempty"`
+	// VALIDATOR_OPERATOR records also withdraw and restake the commission of the
+	// validator operated by the address
+	Type                      RecordType `protobuf:"varint,23,opt,name=type,proto3,enum=RecordType" json:"type,omitempty"`
+	TotalAutostakedCommission int64      `protobuf:"varint,24,opt,name=total_autostaked_commission,json=totalAutostakedCommission,proto3" json:"total_autostaked_commission,omitempty"`
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Record) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Record) GetFrequency() Frequency {
+	if x != nil {
+		return x.Frequency
+	}
+	return Frequency_UNKNOWN
+}
+
+func (x *Record) GetTolerance() int64 {
+	if x != nil {
+		return x.Tolerance
+	}
+	return 0
+}
+
+func (x *Record) GetLastUpdatedUnixTime() int64 {
+	if x != nil {
+		return x.LastUpdatedUnixTime
+	}
+	return 0
+}
+
+func (x *Record) GetTotalAutostakedRewards() int64 {
+	if x != nil {
+		return x.TotalAutostakedRewards
+	}
+	return 0
+}
+
+// Deprecated: Do not use.
+func (x *Record) GetErrorLogs() string {
+	if x != nil {
+		return x.ErrorLogs
+	}
+	return ""
+}
+
+func (x *Record) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *Record) GetErrors() []*ErrorEntry {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *Record) GetValidators() []*ValidatorRestake {
+	if x != nil {
+		return x.Validators
+	}
+	return nil
+}
+
+func (x *Record) GetSchedule() string {
+	if x != nil {
+		return x.Schedule
+	}
+	return ""
+}
+
+func (x *Record) GetConsecutiveFailures() uint32 {
+	if x != nil {
+		return x.ConsecutiveFailures
+	}
+	return 0
+}
+
+func (x *Record) GetSuspended() bool {
+	if x != nil {
+		return x.Suspended
+	}
+	return false
+}
+
+func (x *Record) GetLastRun() *LastRun {
+	if x != nil {
+		return x.LastRun
+	}
+	return nil
+}
+
+func (x *Record) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+func (x *Record) GetMinRewards() int64 {
+	if x != nil && x.MinRewards != nil {
+		return *x.MinRewards
+	}
+	return 0
+}
+
+func (x *Record) GetMinRewardsFeeMultiple() float64 {
+	if x != nil && x.MinRewardsFeeMultiple != nil {
+		return *x.MinRewardsFeeMultiple
+	}
+	return 0
+}
+
+func (x *Record) GetAutoIntervalSeconds() int64 {
+	if x != nil {
+		return x.AutoIntervalSeconds
+	}
+	return 0
+}
+
+func (x *Record) GetAutoEvaluatedUnixTime() int64 {
+	if x != nil {
+		return x.AutoEvaluatedUnixTime
+	}
+	return 0
+}
+
+func (x *Record) GetAllocation() AllocationStrategy {
+	if x != nil {
+		return x.Allocation
+	}
+	return AllocationStrategy_PROPORTIONAL_TO_REWARDS
+}
+
+func (x *Record) GetAllocationValidator() string {
+	if x != nil {
+		return x.AllocationValidator
+	}
+	return ""
+}
+
+func (x *Record) GetAllocationWeights() []*ValidatorWeight {
+	if x != nil {
+		return x.AllocationWeights
+	}
+	return nil
+}
+
+func (x *Record) GetUnhealthyPolicy() UnhealthyPolicy {
+	if x != nil {
+		return x.UnhealthyPolicy
+	}
+	return UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY
+}
+
+func (x *Record) GetType() RecordType {
+	if x != nil {
+		return x.Type
+	}
+	return RecordType_DELEGATOR
+}
+
+func (x *Record) GetTotalAutostakedCommission() int64 {
+	if x != nil {
+		return x.TotalAutostakedCommission
+	}
+	return 0
+}
+
+type ValidatorWeight struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ValidatorAddress string  `protobuf:"bytes,1,opt,name=validator_address,json=validatorAddress,proto3" json:"validator_address,omitempty"`
+	Weight           float64 `protobuf:"fixed64,2,opt,name=weight,proto3" json:"weight,omitempty"`
+}
+
+func (x *ValidatorWeight) Reset() {
+	*x = ValidatorWeight{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidatorWeight) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatorWeight) ProtoMessage() {}
+
+func (x *ValidatorWeight) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatorWeight.ProtoReflect.Descriptor instead.
+func (*ValidatorWeight) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ValidatorWeight) GetValidatorAddress() string {
+	if x != nil {
+		return x.ValidatorAddress
+	}
+	return ""
+}
+
+func (x *ValidatorWeight) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+type LastRun struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UnixTime   int64     `protobuf:"varint,1,opt,name=unix_time,json=unixTime,proto3" json:"unix_time,omitempty"`
+	Outcome    Outcome   `protobuf:"varint,2,opt,name=outcome,proto3,enum=Outcome" json:"outcome,omitempty"`
+	DurationMs int64     `protobuf:"varint,3,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	ErrorCode  ErrorCode `protobuf:"varint,4,opt,name=error_code,json=errorCode,proto3,enum=ErrorCode" json:"error_code,omitempty"`
+}
+
+func (x *LastRun) Reset() {
+	*x = LastRun{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LastRun) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LastRun) ProtoMessage() {}
+
+func (x *LastRun) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LastRun.ProtoReflect.Descriptor instead.
+func (*LastRun) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LastRun) GetUnixTime() int64 {
+	if x != nil {
+		return x.UnixTime
+	}
+	return 0
+}
+
+func (x *LastRun) GetOutcome() Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return Outcome_NONE
+}
+
+func (x *LastRun) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *LastRun) GetErrorCode() ErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ErrorCode_UNCLASSIFIED
+}
+
+type ErrorEntry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	UnixTime int64     `protobuf:"varint,1,opt,name=unix_time,json=unixTime,proto3" json:"unix_time,omitempty"`
+	Code     ErrorCode `protobuf:"varint,2,opt,name=code,proto3,enum=ErrorCode" json:"code,omitempty"`
+	Message  string    `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ErrorEntry) Reset() {
+	*x = ErrorEntry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ErrorEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ErrorEntry) ProtoMessage() {}
+
+func (x *ErrorEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ErrorEntry.ProtoReflect.Descriptor instead.
+func (*ErrorEntry) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ErrorEntry) GetUnixTime() int64 {
+	if x != nil {
+		return x.UnixTime
+	}
+	return 0
+}
+
+func (x *ErrorEntry) GetCode() ErrorCode {
+	if x != nil {
+		return x.Code
+	}
+	return ErrorCode_UNCLASSIFIED
+}
+
+func (x *ErrorEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Event is an immutable entry in the history log. One is appended for every restake attempt.
+type Event struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address      string              `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	UnixNanoTime int64               `protobuf:"varint,2,opt,name=unix_nano_time,json=unixNanoTime,proto3" json:"unix_nano_time,omitempty"`
+	Outcome      Outcome             `protobuf:"varint,3,opt,name=outcome,proto3,enum=Outcome" json:"outcome,omitempty"`
+	TxHash       string              `protobuf:"bytes,4,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	Height       int64               `protobuf:"varint,5,opt,name=height,proto3" json:"height,omitempty"`
+	Validators   []*ValidatorRestake `protobuf:"bytes,6,rep,name=validators,proto3" json:"validators,omitempty"`
+	Fee          int64               `protobuf:"varint,7,opt,name=fee,proto3" json:"fee,omitempty"`
+	DurationMs   int64               `protobuf:"varint,8,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	TotalRewards int64               `protobuf:"varint,9,opt,name=total_rewards,json=totalRewards,proto3" json:"total_rewards,omitempty"`
+	Error        string              `protobuf:"bytes,10,opt,name=error,proto3" json:"error,omitempty"`
+	ChainId      string              `protobuf:"bytes,11,opt,name=chain_id,json=chainId,proto3" json:"chain_id,omitempty"`
+	ErrorCode    ErrorCode           `protobuf:"varint,12,opt,name=error_code,json=errorCode,proto3,enum=ErrorCode" json:"error_code,omitempty"`
+	// gas_used and dry_run_tx are set by dry runs. dry_run_tx is the JSON encoded body
+	// of the transaction that would have been broadcast.
+	GasUsed    uint64     `protobuf:"varint,13,opt,name=gas_used,json=gasUsed,proto3" json:"gas_used,omitempty"`
+	DryRunTx   string     `protobuf:"bytes,14,opt,name=dry_run_tx,json=dryRunTx,proto3" json:"dry_run_tx,omitempty"`
+	SkipReason SkipReason `protobuf:"varint,15,opt,name=skip_reason,json=skipReason,proto3,enum=SkipReason" json:"skip_reason,omitempty"`
+	// unhealthy_validators are the validators that were not delegated to, with the share
+	// they were withheld, and unhealthy_policy decided where that share went
+	UnhealthyValidators []*UnhealthyValidator `protobuf:"bytes,16,rep,name=unhealthy_validators,json=unhealthyValidators,proto3" json:"unhealthy_validators,omitempty"`
+	UnhealthyPolicy     UnhealthyPolicy       `protobuf:"varint,17,opt,name=unhealthy_policy,json=unhealthyPolicy,proto3,enum=UnhealthyPolicy" json:"unhealthy_policy,omitempty"`
+	// commission is the validator commission withdrawn by the restake
+	Commission int64 `protobuf:"varint,18,opt,name=commission,proto3" json:"commission,omitempty"`
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Event) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Event) GetUnixNanoTime() int64 {
+	if x != nil {
+		return x.UnixNanoTime
+	}
+	return 0
+}
+
+func (x *Event) GetOutcome() Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return Outcome_NONE
+}
+
+func (x *Event) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *Event) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Event) GetValidators() []*ValidatorRestake {
+	if x != nil {
+		return x.Validators
+	}
+	return nil
+}
+
+func (x *Event) GetFee() int64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *Event) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *Event) GetTotalRewards() int64 {
+	if x != nil {
+		return x.TotalRewards
+	}
+	return 0
+}
+
+func (x *Event) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Event) GetChainId() string {
+	if x != nil {
+		return x.ChainId
+	}
+	return ""
+}
+
+func (x *Event) GetErrorCode() ErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ErrorCode_UNCLASSIFIED
+}
+
+func (x *Event) GetGasUsed() uint64 {
+	if x != nil {
+		return x.GasUsed
+	}
+	return 0
+}
+
+func (x *Event) GetDryRunTx() string {
+	if x != nil {
+		return x.DryRunTx
+	}
+	return ""
+}
+
+func (x *Event) GetSkipReason() SkipReason {
+	if x != nil {
+		return x.SkipReason
+	}
+	return SkipReason_NOT_SKIPPED
+}
+
+func (x *Event) GetUnhealthyValidators() []*UnhealthyValidator {
+	if x != nil {
+		return x.UnhealthyValidators
+	}
+	return nil
+}
+
+func (x *Event) GetUnhealthyPolicy() UnhealthyPolicy {
+	if x != nil {
+		return x.UnhealthyPolicy
+	}
+	return UnhealthyPolicy_REDISTRIBUTE_PROPORTIONALLY
+}
+
+func (x *Event) GetCommission() int64 {
+	if x != nil {
+		return x.Commission
+	}
+	return 0
+}
+
+type UnhealthyValidator struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ValidatorAddress string          `protobuf:"bytes,1,opt,name=validator_address,json=validatorAddress,proto3" json:"validator_address,omitempty"`
+	Health           ValidatorHealth `protobuf:"varint,2,opt,name=health,proto3,enum=ValidatorHealth" json:"health,omitempty"`
+	Withheld         int64           `protobuf:"varint,3,opt,name=withheld,proto3" json:"withheld,omitempty"`
 }
 
-func (x *Record) Reset() {
-	*x = Record{}
+func (x *UnhealthyValidator) Reset() {
+	*x = UnhealthyValidator{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_types_proto_msgTypes[0]
+		mi := &file_types_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Record) String() string {
+func (x *UnhealthyValidator) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*Record) ProtoMessage() {}
+func (*UnhealthyValidator) ProtoMessage() {}
 
-func (x *Record) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[0]
+func (x *UnhealthyValidator) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -118,53 +1145,191 @@ func (x *Record) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use Record.ProtoReflect.Descriptor instead.
-func (*Record) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{0}
+// Deprecated: Use UnhealthyValidator.ProtoReflect.Descriptor instead.
+func (*UnhealthyValidator) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *Record) GetAddress() string {
+func (x *UnhealthyValidator) GetValidatorAddress() string {
 	if x != nil {
-		return x.Address
+		return x.ValidatorAddress
 	}
 	return ""
 }
 
-func (x *Record) GetFrequency() Frequency {
+func (x *UnhealthyValidator) GetHealth() ValidatorHealth {
 	if x != nil {
-		return x.Frequency
+		return x.Health
 	}
-	return Frequency_UNKNOWN
+	return ValidatorHealth_HEALTHY
 }
 
-func (x *Record) GetTolerance() int64 {
+func (x *UnhealthyValidator) GetWithheld() int64 {
 	if x != nil {
-		return x.Tolerance
+		return x.Withheld
 	}
 	return 0
 }
 
-func (x *Record) GetLastUpdatedUnixTime() int64 {
+type ValidatorRestake struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ValidatorAddress string `protobuf:"bytes,1,opt,name=validator_address,json=validatorAddress,proto3" json:"validator_address,omitempty"`
+	Claimed          int64  `protobuf:"varint,2,opt,name=claimed,proto3" json:"claimed,omitempty"`
+	Delegated        int64  `protobuf:"varint,3,opt,name=delegated,proto3" json:"delegated,omitempty"`
+}
+
+func (x *ValidatorRestake) Reset() {
+	*x = ValidatorRestake{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ValidatorRestake) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValidatorRestake) ProtoMessage() {}
+
+func (x *ValidatorRestake) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValidatorRestake.ProtoReflect.Descriptor instead.
+func (*ValidatorRestake) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ValidatorRestake) GetValidatorAddress() string {
 	if x != nil {
-		return x.LastUpdatedUnixTime
+		return x.ValidatorAddress
 	}
-	return 0
+	return ""
 }
 
-func (x *Record) GetTotalAutostakedRewards() int64 {
+func (x *ValidatorRestake) GetClaimed() int64 {
 	if x != nil {
-		return x.TotalAutostakedRewards
+		return x.Claimed
 	}
 	return 0
 }
 
-func (x *Record) GetErrorLogs() string {
+func (x *ValidatorRestake) GetDelegated() int64 {
 	if x != nil {
-		return x.ErrorLogs
+		return x.Delegated
+	}
+	return 0
+}
+
+// Entry is a single length delimited message of a protobuf export of the store.
+type Entry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Entry:
+	//	*Entry_Record
+	//	*Entry_Event
+	//	*Entry_PausedChain
+	Entry isEntry_Entry `protobuf_oneof:"entry"`
+}
+
+func (x *Entry) Reset() {
+	*x = Entry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_types_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Entry) ProtoMessage() {}
+
+func (x *Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Entry.ProtoReflect.Descriptor instead.
+func (*Entry) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{7}
+}
+
+func (m *Entry) GetEntry() isEntry_Entry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+func (x *Entry) GetRecord() *Record {
+	if x, ok := x.GetEntry().(*Entry_Record); ok {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *Entry) GetEvent() *Event {
+	if x, ok := x.GetEntry().(*Entry_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *Entry) GetPausedChain() string {
+	if x, ok := x.GetEntry().(*Entry_PausedChain); ok {
+		return x.PausedChain
 	}
 	return ""
 }
 
+type isEntry_Entry interface {
+	isEntry_Entry()
+}
+
+type Entry_Record struct {
+	Record *Record `protobuf:"bytes,1,opt,name=record,proto3,oneof"`
+}
+
+type Entry_Event struct {
+	Event *Event `protobuf:"bytes,2,opt,name=event,proto3,oneof"`
+}
+
+type Entry_PausedChain struct {
+	// paused_chain is the id of a chain whose records are paused
+	PausedChain string `protobuf:"bytes,3,opt,name=paused_chain,json=pausedChain,proto3,oneof"`
+}
+
+func (*Entry_Record) isEntry_Entry() {}
+
+func (*Entry_Event) isEntry_Entry() {}
+
+func (*Entry_PausedChain) isEntry_Entry() {}
+
 type Job struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -177,7 +1342,7 @@ type Job struct {
 func (x *Job) Reset() {
 	*x = Job{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_types_proto_msgTypes[1]
+		mi := &file_types_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -190,7 +1355,7 @@ func (x *Job) String() string {
 func (*Job) ProtoMessage() {}
 
 func (x *Job) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[1]
+	mi := &file_types_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -203,7 +1368,7 @@ func (x *Job) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Job.ProtoReflect.Descriptor instead.
 func (*Job) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{1}
+	return file_types_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *Job) GetId() int64 {
@@ -223,7 +1388,7 @@ func (x *Job) GetFrequency() Frequency {
 var File_types_proto protoreflect.FileDescriptor
 
 var file_types_proto_rawDesc = []byte{
-	0x0a, 0x0b, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xf8, 0x01,
+	0x0a, 0x0b, 0x74, 0x79, 0x70, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xde, 0x08,
 	0x0a, 0x06, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72,
 	0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65,
 	0x73, 0x73, 0x12, 0x28, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18,
@@ -237,22 +1402,206 @@ var file_types_proto_rawDesc = []byte{
 	0x38, 0x0a, 0x18, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x73, 0x74, 0x61,
 	0x6b, 0x65, 0x64, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28,
 	0x03, 0x52, 0x16, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41, 0x75, 0x74, 0x6f, 0x73, 0x74, 0x61, 0x6b,
-	0x65, 0x64, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x72, 0x72,
-	0x6f, 0x72, 0x5f, 0x6c, 0x6f, 0x67, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x65,
-	0x72, 0x72, 0x6f, 0x72, 0x4c, 0x6f, 0x67, 0x73, 0x22, 0x3f, 0x0a, 0x03, 0x4a, 0x6f, 0x62, 0x12,
-	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12,
-	0x28, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x09,
-	0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x2a, 0x58, 0x0a, 0x09, 0x46, 0x72, 0x65,
-	0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57,
-	0x4e, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x48, 0x4f, 0x55, 0x52, 0x4c, 0x59, 0x10, 0x01, 0x12,
-	0x0e, 0x0a, 0x0a, 0x51, 0x55, 0x41, 0x52, 0x54, 0x45, 0x52, 0x44, 0x41, 0x59, 0x10, 0x02, 0x12,
-	0x09, 0x0a, 0x05, 0x44, 0x41, 0x49, 0x4c, 0x59, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x57, 0x45,
-	0x45, 0x4b, 0x4c, 0x59, 0x10, 0x04, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x4f, 0x4e, 0x54, 0x48, 0x4c,
-	0x59, 0x10, 0x05, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
-	0x6d, 0x2f, 0x70, 0x6c, 0x75, 0x72, 0x61, 0x6c, 0x2d, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x61, 0x75,
-	0x74, 0x6f, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x72, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x62, 0x06,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x65, 0x64, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x12, 0x21, 0x0a, 0x0a, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x5f, 0x6c, 0x6f, 0x67, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x42, 0x02, 0x18,
+	0x01, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x4c, 0x6f, 0x67, 0x73, 0x12, 0x19, 0x0a, 0x08,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12, 0x23, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72,
+	0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0b, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x31, 0x0a, 0x0a,
+	0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x11, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x74,
+	0x61, 0x6b, 0x65, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x73, 0x63, 0x68, 0x65, 0x64, 0x75, 0x6c, 0x65, 0x12, 0x31, 0x0a, 0x14, 0x63,
+	0x6f, 0x6e, 0x73, 0x65, 0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x5f, 0x66, 0x61, 0x69, 0x6c, 0x75,
+	0x72, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x13, 0x63, 0x6f, 0x6e, 0x73, 0x65,
+	0x63, 0x75, 0x74, 0x69, 0x76, 0x65, 0x46, 0x61, 0x69, 0x6c, 0x75, 0x72, 0x65, 0x73, 0x12, 0x1c,
+	0x0a, 0x09, 0x73, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x09, 0x73, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x65, 0x64, 0x12, 0x23, 0x0a, 0x08,
+	0x6c, 0x61, 0x73, 0x74, 0x5f, 0x72, 0x75, 0x6e, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x08,
+	0x2e, 0x4c, 0x61, 0x73, 0x74, 0x52, 0x75, 0x6e, 0x52, 0x07, 0x6c, 0x61, 0x73, 0x74, 0x52, 0x75,
+	0x6e, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x18, 0x0e, 0x20, 0x01, 0x28,
+	0x08, 0x52, 0x06, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x12, 0x24, 0x0a, 0x0b, 0x6d, 0x69, 0x6e,
+	0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x03, 0x48, 0x00,
+	0x52, 0x0a, 0x6d, 0x69, 0x6e, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x88, 0x01, 0x01, 0x12,
+	0x3c, 0x0a, 0x18, 0x6d, 0x69, 0x6e, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x5f, 0x66,
+	0x65, 0x65, 0x5f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x01, 0x52, 0x15, 0x6d, 0x69, 0x6e, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x46,
+	0x65, 0x65, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x12, 0x32, 0x0a,
+	0x15, 0x61, 0x75, 0x74, 0x6f, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x5f, 0x73,
+	0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x11, 0x20, 0x01, 0x28, 0x03, 0x52, 0x13, 0x61, 0x75,
+	0x74, 0x6f, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64,
+	0x73, 0x12, 0x37, 0x0a, 0x18, 0x61, 0x75, 0x74, 0x6f, 0x5f, 0x65, 0x76, 0x61, 0x6c, 0x75, 0x61,
+	0x74, 0x65, 0x64, 0x5f, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x12, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x15, 0x61, 0x75, 0x74, 0x6f, 0x45, 0x76, 0x61, 0x6c, 0x75, 0x61, 0x74,
+	0x65, 0x64, 0x55, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x33, 0x0a, 0x0a, 0x61, 0x6c,
+	0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x13, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13,
+	0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x72, 0x61, 0x74,
+	0x65, 0x67, 0x79, 0x52, 0x0a, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
+	0x31, 0x0a, 0x14, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x13, 0x61,
+	0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74,
+	0x6f, 0x72, 0x12, 0x3f, 0x0a, 0x12, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x73, 0x18, 0x15, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x57, 0x65, 0x69, 0x67, 0x68, 0x74,
+	0x52, 0x11, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x57, 0x65, 0x69, 0x67,
+	0x68, 0x74, 0x73, 0x12, 0x3b, 0x0a, 0x10, 0x75, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79,
+	0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x16, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e,
+	0x55, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52,
+	0x0f, 0x75, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79,
+	0x12, 0x1f, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x17, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b,
+	0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70,
+	0x65, 0x12, 0x3e, 0x0a, 0x1b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x61, 0x75, 0x74, 0x6f, 0x73,
+	0x74, 0x61, 0x6b, 0x65, 0x64, 0x5f, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x18, 0x20, 0x01, 0x28, 0x03, 0x52, 0x19, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x41, 0x75, 0x74,
+	0x6f, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x64, 0x43, 0x6f, 0x6d, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64,
+	0x73, 0x42, 0x1b, 0x0a, 0x19, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64,
+	0x73, 0x5f, 0x66, 0x65, 0x65, 0x5f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x70, 0x6c, 0x65, 0x22, 0x56,
+	0x0a, 0x0f, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x57, 0x65, 0x69, 0x67, 0x68,
+	0x74, 0x12, 0x2b, 0x0a, 0x11, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x76, 0x61,
+	0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06,
+	0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x22, 0x96, 0x01, 0x0a, 0x07, 0x4c, 0x61, 0x73, 0x74, 0x52,
+	0x75, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x75, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x12,
+	0x22, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e,
+	0x32, 0x08, 0x2e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x63,
+	0x6f, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f,
+	0x6d, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x4d, 0x73, 0x12, 0x29, 0x0a, 0x0a, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6f,
+	0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x43, 0x6f, 0x64, 0x65, 0x52, 0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x22,
+	0x63, 0x0a, 0x0a, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x1b, 0x0a,
+	0x09, 0x75, 0x6e, 0x69, 0x78, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x08, 0x75, 0x6e, 0x69, 0x78, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x1e, 0x0a, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72,
+	0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x6d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x22, 0x8f, 0x05, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x18,
+	0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x24, 0x0a, 0x0e, 0x75, 0x6e, 0x69, 0x78,
+	0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0c, 0x75, 0x6e, 0x69, 0x78, 0x4e, 0x61, 0x6e, 0x6f, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x22,
+	0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x08, 0x2e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52, 0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f,
+	0x6d, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x74, 0x78, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x78, 0x48, 0x61, 0x73, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x68,
+	0x65, 0x69, 0x67, 0x68, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x68, 0x65, 0x69,
+	0x67, 0x68, 0x74, 0x12, 0x31, 0x0a, 0x0a, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72,
+	0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x6f, 0x72, 0x52, 0x65, 0x73, 0x74, 0x61, 0x6b, 0x65, 0x52, 0x0a, 0x76, 0x61, 0x6c, 0x69,
+	0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x66, 0x65, 0x65, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x03, 0x66, 0x65, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x75, 0x72, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6d, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x64,
+	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4d, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x74, 0x6f, 0x74,
+	0x61, 0x6c, 0x5f, 0x72, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x52, 0x65, 0x77, 0x61, 0x72, 0x64, 0x73, 0x12, 0x14,
+	0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x5f, 0x69, 0x64,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x49, 0x64, 0x12,
+	0x29, 0x0a, 0x0a, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x5f, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x0c, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x52,
+	0x09, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x19, 0x0a, 0x08, 0x67, 0x61,
+	0x73, 0x5f, 0x75, 0x73, 0x65, 0x64, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x67, 0x61,
+	0x73, 0x55, 0x73, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x0a, 0x64, 0x72, 0x79, 0x5f, 0x72, 0x75, 0x6e,
+	0x5f, 0x74, 0x78, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x72, 0x79, 0x52, 0x75,
+	0x6e, 0x54, 0x78, 0x12, 0x2c, 0x0a, 0x0b, 0x73, 0x6b, 0x69, 0x70, 0x5f, 0x72, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0b, 0x2e, 0x53, 0x6b, 0x69, 0x70, 0x52,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x52, 0x0a, 0x73, 0x6b, 0x69, 0x70, 0x52, 0x65, 0x61, 0x73, 0x6f,
+	0x6e, 0x12, 0x46, 0x0a, 0x14, 0x75, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x5f, 0x76,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x18, 0x10, 0x20, 0x03, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x55, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x56, 0x61, 0x6c, 0x69, 0x64,
+	0x61, 0x74, 0x6f, 0x72, 0x52, 0x13, 0x75, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x56,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x3b, 0x0a, 0x10, 0x75, 0x6e, 0x68,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x5f, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x11, 0x20,
+	0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x55, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x50,
+	0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x0f, 0x75, 0x6e, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79,
+	0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x12, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x6d,
+	0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x87, 0x01, 0x0a, 0x12, 0x55, 0x6e, 0x68, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x79, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x12, 0x2b, 0x0a,
+	0x11, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61,
+	0x74, 0x6f, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x28, 0x0a, 0x06, 0x68, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x10, 0x2e, 0x56, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x06, 0x68, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x12, 0x1a, 0x0a, 0x08, 0x77, 0x69, 0x74, 0x68, 0x68, 0x65, 0x6c, 0x64,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x77, 0x69, 0x74, 0x68, 0x68, 0x65, 0x6c, 0x64,
+	0x22, 0x77, 0x0a, 0x10, 0x56, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x73,
+	0x74, 0x61, 0x6b, 0x65, 0x12, 0x2b, 0x0a, 0x11, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f,
+	0x72, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x10, 0x76, 0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x63, 0x6c, 0x61, 0x69, 0x6d, 0x65, 0x64, 0x12, 0x1c, 0x0a, 0x09, 0x64,
+	0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09,
+	0x64, 0x65, 0x6c, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x22, 0x78, 0x0a, 0x05, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x21, 0x0a, 0x06, 0x72, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x07, 0x2e, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x48, 0x00, 0x52, 0x06, 0x72,
+	0x65, 0x63, 0x6f, 0x72, 0x64, 0x12, 0x1e, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x06, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x05,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x23, 0x0a, 0x0c, 0x70, 0x61, 0x75, 0x73, 0x65, 0x64, 0x5f,
+	0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x70,
+	0x61, 0x75, 0x73, 0x65, 0x64, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x42, 0x07, 0x0a, 0x05, 0x65, 0x6e,
+	0x74, 0x72, 0x79, 0x22, 0x3f, 0x0a, 0x03, 0x4a, 0x6f, 0x62, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x02, 0x69, 0x64, 0x12, 0x28, 0x0a, 0x09, 0x66, 0x72,
+	0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0a, 0x2e,
+	0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x79, 0x2a, 0x62, 0x0a, 0x09, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x79, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0a,
+	0x0a, 0x06, 0x48, 0x4f, 0x55, 0x52, 0x4c, 0x59, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x51, 0x55,
+	0x41, 0x52, 0x54, 0x45, 0x52, 0x44, 0x41, 0x59, 0x10, 0x02, 0x12, 0x09, 0x0a, 0x05, 0x44, 0x41,
+	0x49, 0x4c, 0x59, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x57, 0x45, 0x45, 0x4b, 0x4c, 0x59, 0x10,
+	0x04, 0x12, 0x0b, 0x0a, 0x07, 0x4d, 0x4f, 0x4e, 0x54, 0x48, 0x4c, 0x59, 0x10, 0x05, 0x12, 0x08,
+	0x0a, 0x04, 0x41, 0x55, 0x54, 0x4f, 0x10, 0x06, 0x2a, 0x59, 0x0a, 0x07, 0x4f, 0x75, 0x74, 0x63,
+	0x6f, 0x6d, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x4e, 0x4f, 0x4e, 0x45, 0x10, 0x00, 0x12, 0x0b, 0x0a,
+	0x07, 0x53, 0x55, 0x43, 0x43, 0x45, 0x53, 0x53, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x46, 0x41,
+	0x49, 0x4c, 0x55, 0x52, 0x45, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x4e, 0x4f, 0x5f, 0x52, 0x45,
+	0x57, 0x41, 0x52, 0x44, 0x53, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x53, 0x49, 0x4d, 0x55, 0x4c,
+	0x41, 0x54, 0x45, 0x44, 0x10, 0x04, 0x12, 0x0b, 0x0a, 0x07, 0x53, 0x4b, 0x49, 0x50, 0x50, 0x45,
+	0x44, 0x10, 0x05, 0x2a, 0x33, 0x0a, 0x0a, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x0d, 0x0a, 0x09, 0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x4f, 0x52, 0x10, 0x00,
+	0x12, 0x16, 0x0a, 0x12, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x41, 0x54, 0x4f, 0x52, 0x5f, 0x4f, 0x50,
+	0x45, 0x52, 0x41, 0x54, 0x4f, 0x52, 0x10, 0x01, 0x2a, 0x8c, 0x01, 0x0a, 0x12, 0x41, 0x6c, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x53, 0x74, 0x72, 0x61, 0x74, 0x65, 0x67, 0x79, 0x12,
+	0x1b, 0x0a, 0x17, 0x50, 0x52, 0x4f, 0x50, 0x4f, 0x52, 0x54, 0x49, 0x4f, 0x4e, 0x41, 0x4c, 0x5f,
+	0x54, 0x4f, 0x5f, 0x52, 0x45, 0x57, 0x41, 0x52, 0x44, 0x53, 0x10, 0x00, 0x12, 0x1e, 0x0a, 0x1a,
+	0x50, 0x52, 0x4f, 0x50, 0x4f, 0x52, 0x54, 0x49, 0x4f, 0x4e, 0x41, 0x4c, 0x5f, 0x54, 0x4f, 0x5f,
+	0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x01, 0x12, 0x0f, 0x0a, 0x0b,
+	0x45, 0x51, 0x55, 0x41, 0x4c, 0x5f, 0x53, 0x50, 0x4c, 0x49, 0x54, 0x10, 0x02, 0x12, 0x14, 0x0a,
+	0x10, 0x53, 0x49, 0x4e, 0x47, 0x4c, 0x45, 0x5f, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x41, 0x54, 0x4f,
+	0x52, 0x10, 0x03, 0x12, 0x12, 0x0a, 0x0e, 0x54, 0x41, 0x52, 0x47, 0x45, 0x54, 0x5f, 0x57, 0x45,
+	0x49, 0x47, 0x48, 0x54, 0x53, 0x10, 0x04, 0x2a, 0x62, 0x0a, 0x0f, 0x55, 0x6e, 0x68, 0x65, 0x61,
+	0x6c, 0x74, 0x68, 0x79, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1f, 0x0a, 0x1b, 0x52, 0x45,
+	0x44, 0x49, 0x53, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x45, 0x5f, 0x50, 0x52, 0x4f, 0x50, 0x4f,
+	0x52, 0x54, 0x49, 0x4f, 0x4e, 0x41, 0x4c, 0x4c, 0x59, 0x10, 0x00, 0x12, 0x18, 0x0a, 0x14, 0x52,
+	0x45, 0x44, 0x49, 0x53, 0x54, 0x52, 0x49, 0x42, 0x55, 0x54, 0x45, 0x5f, 0x45, 0x51, 0x55, 0x41,
+	0x4c, 0x4c, 0x59, 0x10, 0x01, 0x12, 0x14, 0x0a, 0x10, 0x4b, 0x45, 0x45, 0x50, 0x5f, 0x55, 0x4e,
+	0x44, 0x45, 0x4c, 0x45, 0x47, 0x41, 0x54, 0x45, 0x44, 0x10, 0x02, 0x2a, 0x57, 0x0a, 0x0f, 0x56,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x74, 0x6f, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x0b,
+	0x0a, 0x07, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x4a,
+	0x41, 0x49, 0x4c, 0x45, 0x44, 0x10, 0x01, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x4f, 0x4d, 0x42, 0x53,
+	0x54, 0x4f, 0x4e, 0x45, 0x44, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x42, 0x4f, 0x4e,
+	0x44, 0x49, 0x4e, 0x47, 0x10, 0x03, 0x12, 0x0c, 0x0a, 0x08, 0x55, 0x4e, 0x42, 0x4f, 0x4e, 0x44,
+	0x45, 0x44, 0x10, 0x04, 0x2a, 0x32, 0x0a, 0x0a, 0x53, 0x6b, 0x69, 0x70, 0x52, 0x65, 0x61, 0x73,
+	0x6f, 0x6e, 0x12, 0x0f, 0x0a, 0x0b, 0x4e, 0x4f, 0x54, 0x5f, 0x53, 0x4b, 0x49, 0x50, 0x50, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x13, 0x0a, 0x0f, 0x42, 0x45, 0x4c, 0x4f, 0x57, 0x5f, 0x54, 0x48, 0x52,
+	0x45, 0x53, 0x48, 0x4f, 0x4c, 0x44, 0x10, 0x01, 0x2a, 0x98, 0x01, 0x0a, 0x09, 0x45, 0x72, 0x72,
+	0x6f, 0x72, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x10, 0x0a, 0x0c, 0x55, 0x4e, 0x43, 0x4c, 0x41, 0x53,
+	0x53, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x47, 0x52, 0x41, 0x4e,
+	0x54, 0x5f, 0x4d, 0x49, 0x53, 0x53, 0x49, 0x4e, 0x47, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x46,
+	0x45, 0x45, 0x47, 0x52, 0x41, 0x4e, 0x54, 0x5f, 0x45, 0x58, 0x48, 0x41, 0x55, 0x53, 0x54, 0x45,
+	0x44, 0x10, 0x02, 0x12, 0x14, 0x0a, 0x10, 0x49, 0x4e, 0x53, 0x55, 0x46, 0x46, 0x49, 0x43, 0x49,
+	0x45, 0x4e, 0x54, 0x5f, 0x46, 0x45, 0x45, 0x10, 0x03, 0x12, 0x14, 0x0a, 0x10, 0x4e, 0x4f, 0x44,
+	0x45, 0x5f, 0x55, 0x4e, 0x52, 0x45, 0x41, 0x43, 0x48, 0x41, 0x42, 0x4c, 0x45, 0x10, 0x04, 0x12,
+	0x15, 0x0a, 0x11, 0x53, 0x45, 0x51, 0x55, 0x45, 0x4e, 0x43, 0x45, 0x5f, 0x4d, 0x49, 0x53, 0x4d,
+	0x41, 0x54, 0x43, 0x48, 0x10, 0x05, 0x12, 0x0b, 0x0a, 0x07, 0x54, 0x49, 0x4d, 0x45, 0x4f, 0x55,
+	0x54, 0x10, 0x06, 0x42, 0x27, 0x5a, 0x25, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x70, 0x6c, 0x75, 0x72, 0x61, 0x6c, 0x2d, 0x6c, 0x61, 0x62, 0x73, 0x2f, 0x73, 0x74,
+	0x61, 0x6b, 0x65, 0x62, 0x6f, 0x74, 0x2f, 0x74, 0x79, 0x70, 0x65, 0x73, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -267,21 +1616,54 @@ func file_types_proto_rawDescGZIP() []byte {
 	return file_types_proto_rawDescData
 }
 
-var file_types_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_types_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_types_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_types_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_types_proto_goTypes = []interface{}{
-	(Frequency)(0), // 0: Frequency
-	(*Record)(nil), // 1: Record
-	(*Job)(nil),    // 2: Job
+	(Frequency)(0),             // 0: Frequency
+	(Outcome)(0),               // 1: Outcome
+	(RecordType)(0),            // 2: RecordType
+	(AllocationStrategy)(0),    // 3: AllocationStrategy
+	(UnhealthyPolicy)(0),       // 4: UnhealthyPolicy
+	(ValidatorHealth)(0),       // 5: ValidatorHealth
+	(SkipReason)(0),            // 6: SkipReason
+	(ErrorCode)(0),             // 7: ErrorCode
+	(*Record)(nil),             // 8: Record
+	(*ValidatorWeight)(nil),    // 9: ValidatorWeight
+	(*LastRun)(nil),            // 10: LastRun
+	(*ErrorEntry)(nil),         // 11: ErrorEntry
+	(*Event)(nil),              // 12: Event
+	(*UnhealthyValidator)(nil), // 13: UnhealthyValidator
+	(*ValidatorRestake)(nil),   // 14: ValidatorRestake
+	(*Entry)(nil),              // 15: Entry
+	(*Job)(nil),                // 16: Job
 }
 var file_types_proto_depIdxs = []int32{
-	0, // 0: Record.frequency:type_name -> Frequency
-	0, // 1: Job.frequency:type_name -> Frequency
-	2, // [2:2] is the sub-list for method output_type
-	2, // [2:2] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: Record.frequency:type_name -> Frequency
+	11, // 1: Record.errors:type_name -> ErrorEntry
+	14, // 2: Record.validators:type_name -> ValidatorRestake
+	10, // 3: Record.last_run:type_name -> LastRun
+	3,  // 4: Record.allocation:type_name -> AllocationStrategy
+	9,  // 5: Record.allocation_weights:type_name -> ValidatorWeight
+	4,  // 6: Record.unhealthy_policy:type_name -> UnhealthyPolicy
+	2,  // 7: Record.type:type_name -> RecordType
+	1,  // 8: LastRun.outcome:type_name -> Outcome
+	7,  // 9: LastRun.error_code:type_name -> ErrorCode
+	7,  // 10: ErrorEntry.code:type_name -> ErrorCode
+	1,  // 11: Event.outcome:type_name -> Outcome
+	14, // 12: Event.validators:type_name -> ValidatorRestake
+	7,  // 13: Event.error_code:type_name -> ErrorCode
+	6,  // 14: Event.skip_reason:type_name -> SkipReason
+	13, // 15: Event.unhealthy_validators:type_name -> UnhealthyValidator
+	4,  // 16: Event.unhealthy_policy:type_name -> UnhealthyPolicy
+	5,  // 17: UnhealthyValidator.health:type_name -> ValidatorHealth
+	8,  // 18: Entry.record:type_name -> Record
+	12, // 19: Entry.event:type_name -> Event
+	0,  // 20: Job.frequency:type_name -> Frequency
+	21, // [21:21] is the sub-list for method output_type
+	21, // [21:21] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
 }
 
 func init() { file_types_proto_init() }
@@ -303,6 +1685,90 @@ func file_types_proto_init() {
 			}
 		}
 		file_types_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidatorWeight); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LastRun); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ErrorEntry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Event); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UnhealthyValidator); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ValidatorRestake); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Entry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_types_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Job); i {
 			case 0:
 				return &v.state
@@ -315,13 +1781,19 @@ func file_types_proto_init() {
 			}
 		}
 	}
+	file_types_proto_msgTypes[0].OneofWrappers = []interface{}{}
+	file_types_proto_msgTypes[7].OneofWrappers = []interface{}{
+		(*Entry_Record)(nil),
+		(*Entry_Event)(nil),
+		(*Entry_PausedChain)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_types_proto_rawDesc,
-			NumEnums:      1,
-			NumMessages:   2,
+			NumEnums:      8,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},